@@ -0,0 +1,3 @@
+module lab1
+
+go 1.24.0
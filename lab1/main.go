@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"lab1/pesel"
+)
+
+// Kody wyjścia programu, stałe tak aby dało się ich używać w skryptach
+const (
+	kodOK          = 0 // wszystkie numery poprawne
+	kodNiepoprawny = 1 // co najmniej jeden numer niepoprawny
+	kodBladUzycia  = 2 // błędne argumenty lub flagi
+	kodBladWeWy    = 3 // błąd odczytu lub zapisu pliku
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(kodBladUzycia)
+	}
+
+	var kod int
+	switch os.Args[1] {
+	case "gen":
+		kod = komendaGen(os.Args[2:])
+	case "check":
+		kod = komendaCheck(os.Args[2:])
+	case "decode":
+		kod = komendaDecode(os.Args[2:])
+	case "scan":
+		kod = komendaScan(os.Args[2:])
+	case "anon":
+		kod = komendaAnon(os.Args[2:])
+	case "serve":
+		kod = komendaServe(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "Nieznana komenda: %s\n", os.Args[1])
+		printUsage()
+		kod = kodBladUzycia
+	}
+	os.Exit(kod)
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Użycie: pesel <komenda> [flagi] [argumenty]")
+	fmt.Fprintln(os.Stderr, "Komendy:")
+	fmt.Fprintln(os.Stderr, "   gen     - Generowanie numerów PESEL (--from, --to, --sex, -n, --seed, --format)")
+	fmt.Fprintln(os.Stderr, "   check   - Sprawdzenie numerów podanych jako argumenty (--type pesel|nip|regon9|regon14|dowod)")
+	fmt.Fprintln(os.Stderr, "   decode  - Odczytanie daty urodzenia i płci z numeru PESEL (--json)")
+	fmt.Fprintln(os.Stderr, "   scan    - Sprawdzenie kolumny pliku CSV (--column, --delimiter, --header, --type)")
+	fmt.Fprintln(os.Stderr, "   anon    - Pseudonimizacja kolumn pliku CSV (--columns, --key, --delimiter, --header)")
+	fmt.Fprintln(os.Stderr, "   serve   - Serwer HTTP z walidacją, dekodowaniem i generowaniem (--addr)")
+	fmt.Fprintln(os.Stderr, "Kody wyjścia: 0 - poprawne, 1 - niepoprawne numery, 2 - błędne użycie, 3 - błąd pliku")
+}
+
+// parsujFlagi pozwala podawać flagi także po argumentach pozycyjnych,
+// np. "scan plik.csv --column 3". Wszystko po "--" jest argumentem
+// pozycyjnym, np. "check -- -123".
+func parsujFlagi(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pozycyjne []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		// Parse zużywa "--" i zatrzymuje się zaraz za nim
+		if przetworzone := len(args) - fs.NArg(); przetworzone > 0 && args[przetworzone-1] == "--" {
+			return append(pozycyjne, fs.Args()...), nil
+		}
+		if fs.NArg() == 0 {
+			return pozycyjne, nil
+		}
+		pozycyjne = append(pozycyjne, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// regulaBledu wyciąga nazwę naruszonej reguły z błędu walidatora
+func regulaBledu(err error) pesel.Regula {
+	var bladPESEL *pesel.BladPESEL
+	if errors.As(err, &bladPESEL) {
+		return bladPESEL.Regula
+	}
+	var bladIdentyfikatora *pesel.BladIdentyfikatora
+	if errors.As(err, &bladIdentyfikatora) {
+		return bladIdentyfikatora.Regula
+	}
+	return ""
+}
+
+func wybierzWalidator(typ string) (pesel.Walidator, bool) {
+	walidator, ok := pesel.Walidatory[typ]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Nieznany typ identyfikatora: %s\n", typ)
+	}
+	return walidator, ok
+}
+
+func komendaGen(args []string) int {
+	genCmd := flag.NewFlagSet("gen", flag.ContinueOnError)
+	od := genCmd.String("from", "1950-01-01", "Najwcześniejsza data urodzenia (RRRR-MM-DD)")
+	do := genCmd.String("to", "2005-12-31", "Najpóźniejsza data urodzenia (RRRR-MM-DD)")
+	plec := genCmd.String("sex", "", "Płeć: M, K lub pusta dla obu")
+	n := genCmd.Int("n", 10, "Liczba numerów do wygenerowania")
+	ziarno := genCmd.Int64("seed", 0, "Ziarno generatora (0 - losowe)")
+	format := genCmd.String("format", "csv", "Format wyjścia: csv lub json")
+	if _, err := parsujFlagi(genCmd, args); err != nil {
+		return kodBladUzycia
+	}
+
+	opcje := pesel.OpcjeGenerowania{UdzialKobiet: 0.5}
+	var err error
+	if opcje.Od, err = time.Parse("2006-01-02", *od); err != nil {
+		fmt.Fprintf(os.Stderr, "Błędna data --from: %v\n", err)
+		return kodBladUzycia
+	}
+	if opcje.Do, err = time.Parse("2006-01-02", *do); err != nil {
+		fmt.Fprintf(os.Stderr, "Błędna data --to: %v\n", err)
+		return kodBladUzycia
+	}
+	switch *plec {
+	case "M":
+		opcje.UdzialKobiet = 0
+	case "K":
+		opcje.UdzialKobiet = 1
+	case "":
+	default:
+		fmt.Fprintf(os.Stderr, "Błędna płeć --sex: %s (oczekiwano M lub K)\n", *plec)
+		return kodBladUzycia
+	}
+	formatWyjscia := pesel.Format(*format)
+	if formatWyjscia != pesel.FormatCSV && formatWyjscia != pesel.FormatJSON {
+		fmt.Fprintf(os.Stderr, "Błędny format --format: %s (oczekiwano csv lub json)\n", *format)
+		return kodBladUzycia
+	}
+	if err := opcje.Sprawdz(*n); err != nil {
+		fmt.Fprintf(os.Stderr, "Błędne opcje generowania: %v\n", err)
+		return kodBladUzycia
+	}
+	if *ziarno == 0 {
+		*ziarno = time.Now().UnixNano()
+	}
+
+	// opcje są już sprawdzone, więc błąd może pochodzić tylko z zapisu
+	err = pesel.NowyGeneratorZZiarnem(*ziarno).Zapisz(os.Stdout, formatWyjscia, *n, opcje)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Błąd zapisu: %v\n", err)
+		return kodBladWeWy
+	}
+	return kodOK
+}
+
+func komendaCheck(args []string) int {
+	checkCmd := flag.NewFlagSet("check", flag.ContinueOnError)
+	typ := checkCmd.String("type", "pesel", "Typ identyfikatora: pesel, nip, regon9, regon14, dowod")
+	numery, err := parsujFlagi(checkCmd, args)
+	if err != nil {
+		return kodBladUzycia
+	}
+	walidator, ok := wybierzWalidator(*typ)
+	if !ok || len(numery) == 0 {
+		fmt.Fprintln(os.Stderr, "Użycie: pesel check [--type typ] numer [numer...]")
+		return kodBladUzycia
+	}
+
+	kod := kodOK
+	for _, numer := range numery {
+		if err := walidator.Weryfikuj(numer); err != nil {
+			fmt.Printf("%s\tNIEPOPRAWNY\t%s\t%v\n", numer, regulaBledu(err), err)
+			kod = kodNiepoprawny
+		} else {
+			fmt.Printf("%s\tPOPRAWNY\n", numer)
+		}
+	}
+	return kod
+}
+
+func komendaDecode(args []string) int {
+	decodeCmd := flag.NewFlagSet("decode", flag.ContinueOnError)
+	jakoJSON := decodeCmd.Bool("json", false, "Wypisz wynik jako JSON")
+	numery, err := parsujFlagi(decodeCmd, args)
+	if err != nil {
+		return kodBladUzycia
+	}
+	if len(numery) == 0 {
+		fmt.Fprintln(os.Stderr, "Użycie: pesel decode [--json] numer [numer...]")
+		return kodBladUzycia
+	}
+
+	kod := kodOK
+	for _, numer := range numery {
+		numer = pesel.WalidatorPESEL{}.Normalizuj(numer)
+		if err := pesel.WeryfikujPESELTekst(numer); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", numer, err)
+			kod = kodNiepoprawny
+			continue
+		}
+		dane, _ := pesel.DekodujPESELTekst(numer)
+		if *jakoJSON {
+			linia, _ := json.Marshal(map[string]any{
+				"pesel":          numer,
+				"data_urodzenia": dane.DataUrodzenia.Format("2006-01-02"),
+				"stulecie":       dane.Stulecie,
+				"plec":           dane.Plec,
+				"numer_seryjny":  dane.NumerSeryjny,
+			})
+			fmt.Println(string(linia))
+		} else {
+			fmt.Printf("%s\t%s\t%s\t%03d\n", numer, dane.DataUrodzenia.Format("2006-01-02"), dane.Plec, dane.NumerSeryjny)
+		}
+	}
+	return kod
+}
+
+func komendaScan(args []string) int {
+	scanCmd := flag.NewFlagSet("scan", flag.ContinueOnError)
+	kolumna := scanCmd.Int("column", 1, "Numer kolumny z identyfikatorem (od 1)")
+	separator := scanCmd.String("delimiter", ",", "Separator pól w pliku")
+	naglowek := scanCmd.Bool("header", true, "Pierwszy wiersz jest nagłówkiem")
+	typ := scanCmd.String("type", "pesel", "Typ identyfikatora: pesel, nip, regon9, regon14, dowod")
+	pliki, err := parsujFlagi(scanCmd, args)
+	if err != nil {
+		return kodBladUzycia
+	}
+	walidator, ok := wybierzWalidator(*typ)
+	if !ok || len(pliki) != 1 || *kolumna < 1 || len([]rune(*separator)) != 1 {
+		fmt.Fprintln(os.Stderr, "Użycie: pesel scan plik.csv|- [--column n] [--delimiter ,] [--header=true] [--type pesel]")
+		return kodBladUzycia
+	}
+
+	wejscie := io.Reader(os.Stdin)
+	if pliki[0] != "-" {
+		file, err := os.Open(pliki[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Nie udało się otworzyć pliku: %v\n", err)
+			return kodBladWeWy
+		}
+		defer file.Close()
+		wejscie = file
+	}
+
+	reader := csv.NewReader(wejscie)
+	reader.Comma = []rune(*separator)[0]
+	reader.FieldsPerRecord = -1
+
+	sprawdzone, bledne := 0, 0
+	for wiersz := 1; ; wiersz++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Błąd odczytu pliku: %v\n", err)
+			return kodBladWeWy
+		}
+		if wiersz == 1 && *naglowek {
+			continue
+		}
+
+		sprawdzone++
+		if *kolumna > len(record) {
+			fmt.Printf("%d\t\tbrak_kolumny\tbrak kolumny %d\n", wiersz, *kolumna)
+			bledne++
+			continue
+		}
+		numer := record[*kolumna-1]
+		if err := walidator.Weryfikuj(numer); err != nil {
+			fmt.Printf("%d\t%s\t%s\t%v\n", wiersz, numer, regulaBledu(err), err)
+			bledne++
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Sprawdzono %d wierszy, niepoprawnych: %d\n", sprawdzone, bledne)
+	if bledne > 0 {
+		return kodNiepoprawny
+	}
+	return kodOK
+}
+
+func komendaAnon(args []string) int {
+	anonCmd := flag.NewFlagSet("anon", flag.ContinueOnError)
+	kolumny := anonCmd.String("columns", "1", "Numery kolumn z numerami PESEL, oddzielone przecinkami (od 1)")
+	klucz := anonCmd.String("key", "", "Klucz HMAC (domyślnie zmienna PESEL_KLUCZ)")
+	separator := anonCmd.String("delimiter", ",", "Separator pól w pliku")
+	naglowek := anonCmd.Bool("header", true, "Pierwszy wiersz jest nagłówkiem")
+	pliki, err := parsujFlagi(anonCmd, args)
+	if err != nil {
+		return kodBladUzycia
+	}
+	// klucz ze zmiennej środowiskowej jest odczytywany dopiero tutaj, aby nie
+	// pojawił się jako wartość domyślna w pomocy wypisywanej przez -h
+	if *klucz == "" {
+		*klucz = os.Getenv("PESEL_KLUCZ")
+	}
+
+	opcje := pesel.OpcjePrzepisywania{Naglowek: *naglowek}
+	for _, pole := range strings.Split(*kolumny, ",") {
+		kolumna, err := strconv.Atoi(strings.TrimSpace(pole))
+		if err != nil || kolumna < 1 {
+			fmt.Fprintf(os.Stderr, "Błędny numer kolumny: %q\n", pole)
+			return kodBladUzycia
+		}
+		opcje.Kolumny = append(opcje.Kolumny, kolumna)
+	}
+	if len(pliki) != 1 || len([]rune(*separator)) != 1 {
+		fmt.Fprintln(os.Stderr, "Użycie: pesel anon plik.csv|- [--columns 1,3] [--key klucz] [--delimiter ,] [--header=true]")
+		return kodBladUzycia
+	}
+	opcje.Separator = []rune(*separator)[0]
+	pseudonimizator, err := pesel.NowyPseudonimizator([]byte(*klucz))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Błąd: %v\n", err)
+		return kodBladUzycia
+	}
+
+	wejscie := io.Reader(os.Stdin)
+	if pliki[0] != "-" {
+		file, err := os.Open(pliki[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Nie udało się otworzyć pliku: %v\n", err)
+			return kodBladWeWy
+		}
+		defer file.Close()
+		wejscie = file
+	}
+
+	statystyki, err := pseudonimizator.PrzepiszCSV(wejscie, os.Stdout, opcje)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Błąd pseudonimizacji: %v\n", err)
+		return kodBladWeWy
+	}
+	fmt.Fprintf(os.Stderr, "Przepisano %d wierszy, zamieniono %d numerów, niepoprawnych: %d\n",
+		statystyki.Wiersze, statystyki.Zamienione, statystyki.Niepoprawne)
+	if statystyki.Niepoprawne > 0 {
+		return kodNiepoprawny
+	}
+	return kodOK
+}
+
+func komendaServe(args []string) int {
+	serveCmd := flag.NewFlagSet("serve", flag.ContinueOnError)
+	adres := serveCmd.String("addr", ":8080", "Adres, na którym nasłuchuje serwer")
+	if _, err := parsujFlagi(serveCmd, args); err != nil {
+		return kodBladUzycia
+	}
+
+	fmt.Fprintf(os.Stderr, "Serwer PESEL nasłuchuje na %s\n", *adres)
+	serwer := &http.Server{
+		Addr:              *adres,
+		Handler:           NowySerwer(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := serwer.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Błąd serwera: %v\n", err)
+		return kodBladWeWy
+	}
+	return kodOK
+}
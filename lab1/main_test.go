@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParsujFlagi(t *testing.T) {
+	testy := []struct {
+		args      []string
+		pozycyjne string
+		typ       string
+	}{
+		{[]string{"44051401359"}, "44051401359", "pesel"},
+		{[]string{"--type", "nip", "1234563218"}, "1234563218", "nip"},
+		{[]string{"1234563218", "--type", "nip", "5260250274"}, "1234563218 5260250274", "nip"},
+		{[]string{"--", "--type", "nip"}, "--type nip", "pesel"},
+		{[]string{"1234563218", "--type", "nip", "--", "-1", "--type", "dowod"}, "1234563218 -1 --type dowod", "nip"},
+		{[]string{"a", "--", "--"}, "a --", "pesel"},
+	}
+	for _, tt := range testy {
+		fs := flag.NewFlagSet("check", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		typ := fs.String("type", "pesel", "")
+		pozycyjne, err := parsujFlagi(fs, tt.args)
+		if err != nil {
+			t.Errorf("parsujFlagi(%q): %v", tt.args, err)
+			continue
+		}
+		if strings.Join(pozycyjne, " ") != tt.pozycyjne || *typ != tt.typ {
+			t.Errorf("parsujFlagi(%q) = %q, --type %s; oczekiwano %q, --type %s",
+				tt.args, pozycyjne, *typ, tt.pozycyjne, tt.typ)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package pesel
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Błędy zwracane podczas dekodowania numeru PESEL
+var (
+	ErrNiepoprawnaDlugosc = errors.New("numer PESEL musi mieć 11 cyfr")
+	ErrNiepoprawnaCyfra   = errors.New("numer PESEL może zawierać tylko cyfry")
+	ErrNiepoprawnyMiesiac = errors.New("niepoprawny miesiąc")
+	ErrNiepoprawnyDzien   = errors.New("niepoprawny dzień")
+)
+
+// BladDaty opisuje datę zakodowaną w numerze PESEL, która nie istnieje
+// (np. 31 lutego albo miesiąc 13). Powod zawiera ErrNiepoprawnyMiesiac
+// lub ErrNiepoprawnyDzien, więc działa z errors.Is.
+type BladDaty struct {
+	Rok     int
+	Miesiac int
+	Dzien   int
+	Powod   error
+}
+
+func (e *BladDaty) Error() string {
+	return fmt.Sprintf("%v: %04d-%02d-%02d", e.Powod, e.Rok, e.Miesiac, e.Dzien)
+}
+
+func (e *BladDaty) Unwrap() error { return e.Powod }
+
+// DanePESEL przechowuje informacje odczytane z numeru PESEL
+type DanePESEL struct {
+	DataUrodzenia time.Time
+	Stulecie      int    // pierwszy rok stulecia, np. 1900 lub 2000
+	Plec          string // "M" lub "K", tak jak w GenerujPESEL
+	NumerSeryjny  int    // cyfry 7-9 numeru, z zakresu 000-999
+}
+
+// ZgodnyZData sprawdza, czy data urodzenia zapisana w numerze PESEL
+// jest tym samym dniem co podana data.
+func (d DanePESEL) ZgodnyZData(data time.Time) bool {
+	return d.DataUrodzenia.Year() == data.Year() &&
+		d.DataUrodzenia.Month() == data.Month() &&
+		d.DataUrodzenia.Day() == data.Day()
+}
+
+// ParsujPESEL: zamienia tekst na tablicę cyfr numeru PESEL
+// Parametry:
+// - tekst: 11-znakowy ciąg cyfr
+// Wyjscie:
+// Tablica z cyframi numeru PESEL lub błąd
+func ParsujPESEL(tekst string) ([11]int, error) {
+	var cyfryPESEL [11]int
+	if len(tekst) != 11 {
+		return cyfryPESEL, ErrNiepoprawnaDlugosc
+	}
+	for i := 0; i < len(tekst); i++ {
+		if tekst[i] < '0' || tekst[i] > '9' {
+			return cyfryPESEL, ErrNiepoprawnaCyfra
+		}
+		cyfryPESEL[i] = int(tekst[i] - '0')
+	}
+	return cyfryPESEL, nil
+}
+
+// RozkodujMiesiac: odwraca przesunięcie stulecia dodawane przez PoliczMiesiac
+// Parametry:
+// - zakodowany: miesiąc zapisany w numerze PESEL (np. 23 dla marca 2005)
+// Wyjscie:
+// Miesiąc (1-12), pierwszy rok stulecia oraz błąd dla nieznanego kodu
+func RozkodujMiesiac(zakodowany int) (int, int, error) {
+	miesiac := zakodowany % 20
+	if miesiac < 1 || miesiac > 12 {
+		return 0, 0, ErrNiepoprawnyMiesiac
+	}
+	switch zakodowany - miesiac {
+	case 80:
+		return miesiac, 1800, nil
+	case 0:
+		return miesiac, 1900, nil
+	case 20:
+		return miesiac, 2000, nil
+	case 40:
+		return miesiac, 2100, nil
+	case 60:
+		return miesiac, 2200, nil
+	}
+	return 0, 0, ErrNiepoprawnyMiesiac
+}
+
+// DekodujPESEL: odczytuje datę urodzenia, stulecie, płeć i numer seryjny
+// Parametry:
+// - cyfryPESEL: Tablica z cyframi numeru PESEL
+// Wyjscie:
+// Struktura DanePESEL lub błąd (*BladDaty dla nieistniejącej daty)
+func DekodujPESEL(cyfryPESEL [11]int) (DanePESEL, error) {
+	for _, cyfra := range cyfryPESEL {
+		if cyfra < 0 || cyfra > 9 {
+			return DanePESEL{}, ErrNiepoprawnaCyfra
+		}
+	}
+
+	rokWStuleciu := cyfryPESEL[0]*10 + cyfryPESEL[1]
+	zakodowanyMiesiac := cyfryPESEL[2]*10 + cyfryPESEL[3]
+	dzien := cyfryPESEL[4]*10 + cyfryPESEL[5]
+
+	miesiac, stulecie, err := RozkodujMiesiac(zakodowanyMiesiac)
+	if err != nil {
+		return DanePESEL{}, &BladDaty{Rok: rokWStuleciu, Miesiac: zakodowanyMiesiac, Dzien: dzien, Powod: err}
+	}
+	rok := stulecie + rokWStuleciu
+
+	// time.Date normalizuje np. 31 lutego do 3 marca, więc porównujemy wynik
+	data := time.Date(rok, time.Month(miesiac), dzien, 0, 0, 0, 0, time.UTC)
+	if dzien < 1 || data.Day() != dzien || int(data.Month()) != miesiac {
+		return DanePESEL{}, &BladDaty{Rok: rok, Miesiac: miesiac, Dzien: dzien, Powod: ErrNiepoprawnyDzien}
+	}
+
+	plec := "K"
+	if cyfryPESEL[9]%2 == 1 {
+		plec = "M"
+	}
+
+	return DanePESEL{
+		DataUrodzenia: data,
+		Stulecie:      stulecie,
+		Plec:          plec,
+		NumerSeryjny:  cyfryPESEL[6]*100 + cyfryPESEL[7]*10 + cyfryPESEL[8],
+	}, nil
+}
+
+// DekodujPESELTekst: dekoduje numer PESEL podany jako tekst
+// Parametry:
+// - tekst: 11-znakowy ciąg cyfr
+// Wyjscie:
+// Struktura DanePESEL lub błąd
+func DekodujPESELTekst(tekst string) (DanePESEL, error) {
+	cyfryPESEL, err := ParsujPESEL(tekst)
+	if err != nil {
+		return DanePESEL{}, err
+	}
+	return DekodujPESEL(cyfryPESEL)
+}
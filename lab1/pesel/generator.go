@@ -0,0 +1,220 @@
+package pesel
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// Format określa sposób zapisu wygenerowanych numerów
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// liczba różnych numerów PESEL dla jednej daty i płci
+// (1000 numerów seryjnych razy 5 cyfr płci)
+const numerowNaDzienIPlec = 1000 * 5
+
+var (
+	ErrNiepoprawnyZakres = errors.New("data początkowa jest późniejsza niż końcowa")
+	ErrZaMaloKombinacji  = errors.New("zakres dat jest zbyt mały dla żądanej liczby unikalnych numerów")
+	ErrNieznanyFormat    = errors.New("nieznany format zapisu")
+	ErrNiepoprawnyUdzial = errors.New("udział kobiet musi być z przedziału 0-1")
+	ErrUjemnaLiczba      = errors.New("liczba numerów nie może być ujemna")
+)
+
+// OpcjeGenerowania opisuje zbiór numerów PESEL do wygenerowania
+type OpcjeGenerowania struct {
+	Od           time.Time // najwcześniejsza data urodzenia (włącznie)
+	Do           time.Time // najpóźniejsza data urodzenia (włącznie)
+	UdzialKobiet float64   // prawdopodobieństwo wylosowania płci "K", od 0 do 1
+}
+
+// OsobaPESEL to pojedynczy wygenerowany numer wraz z danymi, z których powstał
+type OsobaPESEL struct {
+	PESEL         string `json:"pesel"`
+	DataUrodzenia string `json:"data_urodzenia"`
+	Plec          string `json:"plec"`
+}
+
+// Generator tworzy numery PESEL z własnego źródła liczb losowych,
+// dzięki czemu to samo ziarno daje zawsze ten sam zbiór numerów.
+type Generator struct {
+	rnd *rand.Rand
+}
+
+// NowyGenerator tworzy generator korzystający z podanego *rand.Rand
+func NowyGenerator(rnd *rand.Rand) *Generator {
+	return &Generator{rnd: rnd}
+}
+
+// NowyGeneratorZZiarnem tworzy generator z deterministycznym ziarnem
+func NowyGeneratorZZiarnem(ziarno int64) *Generator {
+	return NowyGenerator(rand.New(rand.NewSource(ziarno)))
+}
+
+// Generuj: generuje numer PESEL dla podanej daty i płci
+// Parametry:
+// - dataUrodzenia: data urodzenia
+// - plec: znak "M" lub "K"
+// Wyjscie:
+// Tablica z cyframi numeru PESEL lub ErrRokPozaZakresem
+func (g *Generator) Generuj(dataUrodzenia time.Time, plec string) ([11]int, error) {
+	return generujPESEL(g.rnd.Intn, dataUrodzenia, plec)
+}
+
+// GenerujWiele: generuje n różnych numerów PESEL zgodnie z opcjami
+// Parametry:
+// - n: liczba numerów
+// - opcje: zakres dat i rozkład płci
+// Wyjscie:
+// Lista osób w kolejności generowania lub błąd
+func (g *Generator) GenerujWiele(n int, opcje OpcjeGenerowania) ([]OsobaPESEL, error) {
+	var osoby []OsobaPESEL
+	err := g.generujUnikalne(n, opcje, func(o OsobaPESEL) error {
+		osoby = append(osoby, o)
+		return nil
+	})
+	return osoby, err
+}
+
+// Zapisz: generuje n różnych numerów PESEL i od razu zapisuje je do w
+// Parametry:
+// - w: miejsce zapisu
+// - format: FormatCSV lub FormatJSON
+// - n: liczba numerów
+// - opcje: zakres dat i rozkład płci
+// Wyjscie:
+// błąd generowania lub zapisu
+func (g *Generator) Zapisz(w io.Writer, format Format, n int, opcje OpcjeGenerowania) error {
+	// błędne opcje muszą zostać wykryte, zanim cokolwiek trafi do w
+	if err := opcje.Sprawdz(n); err != nil {
+		return err
+	}
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"pesel", "data_urodzenia", "plec"}); err != nil {
+			return err
+		}
+		err := g.generujUnikalne(n, opcje, func(o OsobaPESEL) error {
+			return cw.Write([]string{o.PESEL, o.DataUrodzenia, o.Plec})
+		})
+		if err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		pierwszy := true
+		err := g.generujUnikalne(n, opcje, func(o OsobaPESEL) error {
+			separator := ",\n"
+			if pierwszy {
+				separator = "\n"
+				pierwszy = false
+			}
+			linia, err := json.Marshal(o)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "%s  %s", separator, linia)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "\n]\n")
+		return err
+	}
+	return fmt.Errorf("%w: %q", ErrNieznanyFormat, format)
+}
+
+// Sprawdz: sprawdza, czy da się wygenerować n różnych numerów zgodnie z opcjami
+// Parametry:
+// - n: liczba numerów
+// Wyjscie:
+// nil lub błąd opisujący niepoprawną opcję
+func (o OpcjeGenerowania) Sprawdz(n int) error {
+	_, _, err := o.zakres(n)
+	return err
+}
+
+// zakres zwraca pierwszy dzień zakresu dat i liczbę dni w zakresie
+func (o OpcjeGenerowania) zakres(n int) (time.Time, int, error) {
+	if n < 0 {
+		return time.Time{}, 0, ErrUjemnaLiczba
+	}
+	if o.UdzialKobiet < 0 || o.UdzialKobiet > 1 {
+		return time.Time{}, 0, ErrNiepoprawnyUdzial
+	}
+	od := obetnijDoDnia(o.Od)
+	do := obetnijDoDnia(o.Do)
+	if do.Before(od) {
+		return time.Time{}, 0, ErrNiepoprawnyZakres
+	}
+	if od.Year() < MinRokPESEL || do.Year() > MaxRokPESEL {
+		return time.Time{}, 0, ErrRokPozaZakresem
+	}
+	dni := int(do.Sub(od).Hours()/24) + 1
+	if n > dni*numerowNaDzienIPlec {
+		return time.Time{}, 0, ErrZaMaloKombinacji
+	}
+	return od, dni, nil
+}
+
+func (g *Generator) generujUnikalne(n int, opcje OpcjeGenerowania, zapisz func(OsobaPESEL) error) error {
+	od, dni, err := opcje.zakres(n)
+	if err != nil {
+		return err
+	}
+
+	uzyte := make(map[[11]int]struct{}, n)
+	for len(uzyte) < n {
+		data := od.AddDate(0, 0, g.rnd.Intn(dni))
+		plec := "M"
+		if g.rnd.Float64() < opcje.UdzialKobiet {
+			plec = "K"
+		}
+		cyfryPESEL, err := g.Generuj(data, plec)
+		if err != nil {
+			return err
+		}
+		if _, powtorzony := uzyte[cyfryPESEL]; powtorzony {
+			continue
+		}
+		uzyte[cyfryPESEL] = struct{}{}
+
+		err = zapisz(OsobaPESEL{
+			PESEL:         PESELTekst(cyfryPESEL),
+			DataUrodzenia: data.Format("2006-01-02"),
+			Plec:          plec,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PESELTekst zamienia tablicę cyfr na 11-znakowy tekst
+func PESELTekst(cyfryPESEL [11]int) string {
+	var tekst [11]byte
+	for i, cyfra := range cyfryPESEL {
+		tekst[i] = byte('0' + cyfra)
+	}
+	return string(tekst[:])
+}
+
+func obetnijDoDnia(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,115 @@
+package pesel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func dzien(rok int, miesiac time.Month, dzien int) time.Time {
+	return time.Date(rok, miesiac, dzien, 0, 0, 0, 0, time.UTC)
+}
+
+// Zapisz nie może zapisać nagłówka CSV ani "[" przed wykryciem błędnych opcji
+func TestZapiszBledneOpcjeNicNieZapisuje(t *testing.T) {
+	poprawne := OpcjeGenerowania{Od: dzien(1950, 1, 1), Do: dzien(2005, 12, 31), UdzialKobiet: 0.5}
+	testy := []struct {
+		nazwa string
+		n     int
+		zmien func(*OpcjeGenerowania)
+		blad  error
+	}{
+		{"ujemna liczba numerów", -3, func(o *OpcjeGenerowania) {}, ErrUjemnaLiczba},
+		{"udział kobiet ujemny", 1, func(o *OpcjeGenerowania) { o.UdzialKobiet = -0.1 }, ErrNiepoprawnyUdzial},
+		{"udział kobiet ponad 1", 1, func(o *OpcjeGenerowania) { o.UdzialKobiet = 1.5 }, ErrNiepoprawnyUdzial},
+		{"odwrócony zakres", 1, func(o *OpcjeGenerowania) { o.Od, o.Do = o.Do, o.Od }, ErrNiepoprawnyZakres},
+		{"rok przed 1800", 1, func(o *OpcjeGenerowania) { o.Od = dzien(1799, 12, 31) }, ErrRokPozaZakresem},
+		{"rok po 2299", 1, func(o *OpcjeGenerowania) { o.Do = dzien(2300, 1, 1) }, ErrRokPozaZakresem},
+		{"za mało kombinacji", numerowNaDzienIPlec + 1, func(o *OpcjeGenerowania) {
+			o.Od, o.Do = dzien(2000, 1, 1), dzien(2000, 1, 1)
+		}, ErrZaMaloKombinacji},
+	}
+	for _, tt := range testy {
+		for _, format := range []Format{FormatCSV, FormatJSON} {
+			opcje := poprawne
+			tt.zmien(&opcje)
+			if err := opcje.Sprawdz(tt.n); !errors.Is(err, tt.blad) {
+				t.Errorf("%s: Sprawdz zwrócił %v, oczekiwano %v", tt.nazwa, err, tt.blad)
+			}
+			var wyjscie bytes.Buffer
+			err := NowyGeneratorZZiarnem(1).Zapisz(&wyjscie, format, tt.n, opcje)
+			if !errors.Is(err, tt.blad) {
+				t.Errorf("%s (%s): Zapisz zwrócił %v, oczekiwano %v", tt.nazwa, format, err, tt.blad)
+			}
+			if wyjscie.Len() > 0 {
+				t.Errorf("%s (%s): Zapisz zapisał %q przed zwróceniem błędu", tt.nazwa, format, wyjscie.String())
+			}
+		}
+	}
+
+	var wyjscie bytes.Buffer
+	if err := NowyGeneratorZZiarnem(1).Zapisz(&wyjscie, "xml", 1, poprawne); !errors.Is(err, ErrNieznanyFormat) || wyjscie.Len() > 0 {
+		t.Errorf("nieznany format: błąd %v, zapisano %q", err, wyjscie.String())
+	}
+}
+
+func TestZapisz(t *testing.T) {
+	opcje := OpcjeGenerowania{Od: dzien(2000, 1, 1), Do: dzien(2000, 1, 1), UdzialKobiet: 1}
+	const n = 20
+
+	var jsonWyjscie bytes.Buffer
+	if err := NowyGeneratorZZiarnem(7).Zapisz(&jsonWyjscie, FormatJSON, n, opcje); err != nil {
+		t.Fatal(err)
+	}
+	var osoby []OsobaPESEL
+	if err := json.Unmarshal(jsonWyjscie.Bytes(), &osoby); err != nil {
+		t.Fatalf("niepoprawny JSON %q: %v", jsonWyjscie.String(), err)
+	}
+	if len(osoby) != n {
+		t.Fatalf("zapisano %d osób, oczekiwano %d", len(osoby), n)
+	}
+	for _, osoba := range osoby {
+		if err := WeryfikujPESELTekst(osoba.PESEL); err != nil || osoba.Plec != "K" || osoba.DataUrodzenia != "2000-01-01" {
+			t.Errorf("niepoprawna osoba %+v: %v", osoba, err)
+		}
+	}
+
+	var csvWyjscie bytes.Buffer
+	if err := NowyGeneratorZZiarnem(7).Zapisz(&csvWyjscie, FormatCSV, n, opcje); err != nil {
+		t.Fatal(err)
+	}
+	wiersze, err := csv.NewReader(&csvWyjscie).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wiersze) != n+1 || wiersze[0][0] != "pesel" {
+		t.Fatalf("CSV ma %d wierszy (nagłówek %v), oczekiwano nagłówka i %d", len(wiersze), wiersze[0], n)
+	}
+	for i, osoba := range osoby {
+		if wiersze[i+1][0] != osoba.PESEL {
+			t.Errorf("wiersz %d: CSV %s, JSON %s; to samo ziarno powinno dać te same numery", i+1, wiersze[i+1][0], osoba.PESEL)
+		}
+	}
+}
+
+// To samo ziarno musi dawać ten sam zbiór numerów
+func TestGeneratorDeterministyczny(t *testing.T) {
+	opcje := OpcjeGenerowania{
+		Od:           time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC),
+		Do:           time.Date(2005, 12, 31, 0, 0, 0, 0, time.UTC),
+		UdzialKobiet: 0.5,
+	}
+	pierwsze, err := NowyGeneratorZZiarnem(42).GenerujWiele(100, opcje)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drugie, _ := NowyGenerator(rand.New(rand.NewSource(42))).GenerujWiele(100, opcje)
+	if !reflect.DeepEqual(pierwsze, drugie) {
+		t.Error("generatory z tym samym ziarnem dały różne numery")
+	}
+}
@@ -0,0 +1,265 @@
+package pesel
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Walidator definiuje sprawdzanie, normalizację i generowanie
+// jednego rodzaju identyfikatora (PESEL, NIP, REGON, dowód osobisty)
+type Walidator interface {
+	Nazwa() string
+	Normalizuj(tekst string) string
+	Weryfikuj(tekst string) error
+	Generuj(rnd *rand.Rand) string
+}
+
+// RegulaFormat oznacza niepoprawny układ liter i cyfr (np. w numerze dowodu)
+const RegulaFormat Regula = "format"
+
+var ErrNiepoprawnyFormat = errors.New("niepoprawny format identyfikatora")
+
+// BladIdentyfikatora jest zwracany przez walidatory NIP, REGON i dowodu osobistego
+type BladIdentyfikatora struct {
+	Identyfikator string
+	Regula        Regula
+	Powod         error
+}
+
+func (e *BladIdentyfikatora) Error() string {
+	return fmt.Sprintf("%s niepoprawny (%s): %v", e.Identyfikator, e.Regula, e.Powod)
+}
+
+func (e *BladIdentyfikatora) Unwrap() error { return e.Powod }
+
+// Walidatory zawiera wszystkie obsługiwane identyfikatory według nazwy
+var Walidatory = map[string]Walidator{
+	"pesel":   WalidatorPESEL{},
+	"nip":     WalidatorNIP{},
+	"regon9":  WalidatorREGON9{},
+	"regon14": WalidatorREGON14{},
+	"dowod":   WalidatorDowodu{},
+}
+
+// usunSeparatory usuwa myślniki i białe znaki, które często pojawiają się
+// w numerach wpisywanych ręcznie (np. "123-456-32-18")
+func usunSeparatory(tekst string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(tekst))
+}
+
+// parsujCyfry zamienia tekst o podanej długości na listę cyfr
+func parsujCyfry(nazwa, tekst string, dlugosc int) ([]int, error) {
+	if len(tekst) != dlugosc {
+		return nil, &BladIdentyfikatora{Identyfikator: nazwa, Regula: RegulaDlugosc,
+			Powod: fmt.Errorf("oczekiwano %d cyfr, otrzymano %d znaków", dlugosc, len(tekst))}
+	}
+	cyfry := make([]int, dlugosc)
+	for i := 0; i < len(tekst); i++ {
+		if tekst[i] < '0' || tekst[i] > '9' {
+			return nil, &BladIdentyfikatora{Identyfikator: nazwa, Regula: RegulaCyfry,
+				Powod: fmt.Errorf("znak %q na pozycji %d nie jest cyfrą", tekst[i], i+1)}
+		}
+		cyfry[i] = int(tekst[i] - '0')
+	}
+	return cyfry, nil
+}
+
+// sumaWazona mnoży kolejne cyfry przez wagi i sumuje wyniki
+func sumaWazona(cyfry []int, wagi []int) int {
+	suma := 0
+	for i, waga := range wagi {
+		suma += cyfry[i] * waga
+	}
+	return suma
+}
+
+func losoweCyfry(rnd *rand.Rand, n int) []int {
+	cyfry := make([]int, n)
+	for i := range cyfry {
+		cyfry[i] = rnd.Intn(10)
+	}
+	return cyfry
+}
+
+func cyfryDoTekstu(cyfry []int) string {
+	var sb strings.Builder
+	for _, cyfra := range cyfry {
+		sb.WriteByte(byte('0' + cyfra))
+	}
+	return sb.String()
+}
+
+// WalidatorPESEL udostępnia WeryfikujPESELTekst przez interfejs Walidator
+type WalidatorPESEL struct{}
+
+func (WalidatorPESEL) Nazwa() string                  { return "PESEL" }
+func (WalidatorPESEL) Normalizuj(tekst string) string { return usunSeparatory(tekst) }
+func (w WalidatorPESEL) Weryfikuj(tekst string) error {
+	return WeryfikujPESELTekst(w.Normalizuj(tekst))
+}
+func (WalidatorPESEL) Generuj(rnd *rand.Rand) string {
+	od := time.Date(1930, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := od.AddDate(0, 0, rnd.Intn(80*365))
+	plec := "M"
+	if rnd.Intn(2) == 0 {
+		plec = "K"
+	}
+	cyfryPESEL, _ := NowyGenerator(rnd).Generuj(data, plec)
+	return PESELTekst(cyfryPESEL)
+}
+
+// WalidatorNIP sprawdza 10-cyfrowy numer identyfikacji podatkowej
+type WalidatorNIP struct{}
+
+var wagiNIP = []int{6, 5, 7, 2, 3, 4, 5, 6, 7}
+
+func (WalidatorNIP) Nazwa() string { return "NIP" }
+
+// Normalizuj usuwa separatory oraz opcjonalny prefiks kraju "PL"
+func (WalidatorNIP) Normalizuj(tekst string) string {
+	tekst = usunSeparatory(tekst)
+	if len(tekst) > 2 && strings.EqualFold(tekst[:2], "PL") {
+		tekst = tekst[2:]
+	}
+	return tekst
+}
+
+func (w WalidatorNIP) Weryfikuj(tekst string) error {
+	cyfry, err := parsujCyfry(w.Nazwa(), w.Normalizuj(tekst), 10)
+	if err != nil {
+		return err
+	}
+	// reszta 10 oznacza numer, który nie może zostać nadany
+	kontrolna := sumaWazona(cyfry, wagiNIP) % 11
+	if kontrolna == 10 || kontrolna != cyfry[9] {
+		return &BladIdentyfikatora{Identyfikator: w.Nazwa(), Regula: RegulaSumaKontrolna, Powod: ErrNiepoprawnaCyfraKontrolna}
+	}
+	return nil
+}
+
+func (WalidatorNIP) Generuj(rnd *rand.Rand) string {
+	for {
+		cyfry := losoweCyfry(rnd, 9)
+		// pierwsze trzy cyfry to kod urzędu skarbowego, który nie zaczyna się od zera
+		if cyfry[0] == 0 {
+			continue
+		}
+		kontrolna := sumaWazona(cyfry, wagiNIP) % 11
+		if kontrolna == 10 {
+			continue
+		}
+		return cyfryDoTekstu(append(cyfry, kontrolna))
+	}
+}
+
+// WalidatorREGON9 sprawdza 9-cyfrowy numer REGON
+type WalidatorREGON9 struct{}
+
+var wagiREGON9 = []int{8, 9, 2, 3, 4, 5, 6, 7}
+
+func (WalidatorREGON9) Nazwa() string                  { return "REGON" }
+func (WalidatorREGON9) Normalizuj(tekst string) string { return usunSeparatory(tekst) }
+func (w WalidatorREGON9) Weryfikuj(tekst string) error {
+	cyfry, err := parsujCyfry(w.Nazwa(), w.Normalizuj(tekst), 9)
+	if err != nil {
+		return err
+	}
+	if cyfraKontrolnaREGON(cyfry, wagiREGON9) != cyfry[8] {
+		return &BladIdentyfikatora{Identyfikator: w.Nazwa(), Regula: RegulaSumaKontrolna, Powod: ErrNiepoprawnaCyfraKontrolna}
+	}
+	return nil
+}
+func (WalidatorREGON9) Generuj(rnd *rand.Rand) string {
+	cyfry := losoweCyfry(rnd, 8)
+	return cyfryDoTekstu(append(cyfry, cyfraKontrolnaREGON(cyfry, wagiREGON9)))
+}
+
+// WalidatorREGON14 sprawdza 14-cyfrowy numer REGON jednostki lokalnej,
+// którego pierwsze 9 cyfr musi być poprawnym numerem REGON-9
+type WalidatorREGON14 struct{}
+
+var wagiREGON14 = []int{2, 4, 8, 5, 0, 9, 7, 3, 6, 1, 2, 4, 8}
+
+func (WalidatorREGON14) Nazwa() string                  { return "REGON-14" }
+func (WalidatorREGON14) Normalizuj(tekst string) string { return usunSeparatory(tekst) }
+func (w WalidatorREGON14) Weryfikuj(tekst string) error {
+	cyfry, err := parsujCyfry(w.Nazwa(), w.Normalizuj(tekst), 14)
+	if err != nil {
+		return err
+	}
+	if cyfraKontrolnaREGON(cyfry, wagiREGON9) != cyfry[8] ||
+		cyfraKontrolnaREGON(cyfry, wagiREGON14) != cyfry[13] {
+		return &BladIdentyfikatora{Identyfikator: w.Nazwa(), Regula: RegulaSumaKontrolna, Powod: ErrNiepoprawnaCyfraKontrolna}
+	}
+	return nil
+}
+func (WalidatorREGON14) Generuj(rnd *rand.Rand) string {
+	cyfry := losoweCyfry(rnd, 8)
+	cyfry = append(cyfry, cyfraKontrolnaREGON(cyfry, wagiREGON9))
+	cyfry = append(cyfry, losoweCyfry(rnd, 4)...)
+	return cyfryDoTekstu(append(cyfry, cyfraKontrolnaREGON(cyfry, wagiREGON14)))
+}
+
+// cyfraKontrolnaREGON liczy resztę z dzielenia przez 11, gdzie reszta 10 daje 0
+func cyfraKontrolnaREGON(cyfry []int, wagi []int) int {
+	return sumaWazona(cyfry, wagi) % 11 % 10
+}
+
+// WalidatorDowodu sprawdza numer dowodu osobistego w formacie ABC123456,
+// gdzie pierwsza cyfra jest cyfrą kontrolną
+type WalidatorDowodu struct{}
+
+var wagiDowodu = []int{7, 3, 1, 0, 7, 3, 1, 7, 3}
+
+func (WalidatorDowodu) Nazwa() string { return "dowód osobisty" }
+func (WalidatorDowodu) Normalizuj(tekst string) string {
+	return strings.ToUpper(usunSeparatory(tekst))
+}
+
+func (w WalidatorDowodu) Weryfikuj(tekst string) error {
+	tekst = w.Normalizuj(tekst)
+	if len(tekst) != 9 {
+		return &BladIdentyfikatora{Identyfikator: w.Nazwa(), Regula: RegulaDlugosc,
+			Powod: fmt.Errorf("oczekiwano 9 znaków, otrzymano %d", len(tekst))}
+	}
+	// litery mają wartości A=10 ... Z=35, tak jak w systemie 36-kowym
+	wartosci := make([]int, 9)
+	for i := 0; i < len(tekst); i++ {
+		znak := tekst[i]
+		switch {
+		case i < 3 && znak >= 'A' && znak <= 'Z':
+			wartosci[i] = int(znak-'A') + 10
+		case i >= 3 && znak >= '0' && znak <= '9':
+			wartosci[i] = int(znak - '0')
+		default:
+			return &BladIdentyfikatora{Identyfikator: w.Nazwa(), Regula: RegulaFormat, Powod: ErrNiepoprawnyFormat}
+		}
+	}
+	if sumaWazona(wartosci, wagiDowodu)%10 != wartosci[3] {
+		return &BladIdentyfikatora{Identyfikator: w.Nazwa(), Regula: RegulaSumaKontrolna, Powod: ErrNiepoprawnaCyfraKontrolna}
+	}
+	return nil
+}
+
+func (WalidatorDowodu) Generuj(rnd *rand.Rand) string {
+	wartosci := make([]int, 9)
+	var sb strings.Builder
+	for i := 0; i < 3; i++ {
+		wartosci[i] = rnd.Intn(26) + 10
+		sb.WriteByte(byte('A' + wartosci[i] - 10))
+	}
+	for i := 4; i < 9; i++ {
+		wartosci[i] = rnd.Intn(10)
+	}
+	wartosci[3] = sumaWazona(wartosci, wagiDowodu) % 10
+	sb.WriteString(cyfryDoTekstu(wartosci[3:]))
+	return sb.String()
+}
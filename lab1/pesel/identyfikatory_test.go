@@ -0,0 +1,102 @@
+package pesel
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestWalidatory(t *testing.T) {
+	testy := []struct {
+		nazwa     string
+		walidator Walidator
+		numer     string
+		regula    Regula // pusta dla poprawnego numeru
+	}{
+		{"NIP poprawny", WalidatorNIP{}, "1234563218", ""},
+		{"NIP z myślnikami", WalidatorNIP{}, "123-456-32-18", ""},
+		{"NIP z prefiksem PL", WalidatorNIP{}, "PL1234563218", ""},
+		{"NIP z prefiksem pl", WalidatorNIP{}, "pl 526-025-02-74", ""},
+		{"NIP zła cyfra kontrolna", WalidatorNIP{}, "1234563219", RegulaSumaKontrolna},
+		// reszta 10 nie może zostać cyfrą kontrolną, nawet jako 0
+		{"NIP z resztą 10", WalidatorNIP{}, "1234567080", RegulaSumaKontrolna},
+		{"NIP za krótki", WalidatorNIP{}, "123456321", RegulaDlugosc},
+		{"NIP z literą", WalidatorNIP{}, "12345632A8", RegulaCyfry},
+
+		{"REGON-9 poprawny", WalidatorREGON9{}, "123456785", ""},
+		{"REGON-9 poprawny 2", WalidatorREGON9{}, "732065814", ""},
+		// reszta 10 daje cyfrę kontrolną 0
+		{"REGON-9 z resztą 10", WalidatorREGON9{}, "123456160", ""},
+		{"REGON-9 z resztą 10 i cyfrą 1", WalidatorREGON9{}, "123456161", RegulaSumaKontrolna},
+		{"REGON-9 zła cyfra kontrolna", WalidatorREGON9{}, "123456784", RegulaSumaKontrolna},
+		{"REGON-9 za długi", WalidatorREGON9{}, "1234567850", RegulaDlugosc},
+		{"REGON-9 z literą", WalidatorREGON9{}, "12345678X", RegulaCyfry},
+
+		{"REGON-14 poprawny", WalidatorREGON14{}, "12345678512347", ""},
+		{"REGON-14 z resztą 10", WalidatorREGON14{}, "12345678510050", ""},
+		{"REGON-14 zła cyfra kontrolna", WalidatorREGON14{}, "12345678512348", RegulaSumaKontrolna},
+		// 14. cyfra się zgadza, ale pierwsze 9 cyfr nie jest poprawnym REGON-9
+		{"REGON-14 z błędnym REGON-9", WalidatorREGON14{}, "12345678612342", RegulaSumaKontrolna},
+		{"REGON-14 za krótki", WalidatorREGON14{}, "123456785", RegulaDlugosc},
+
+		{"dowód poprawny", WalidatorDowodu{}, "ABA300000", ""},
+		{"dowód małymi literami", WalidatorDowodu{}, "aba 300000", ""},
+		{"dowód zła cyfra kontrolna", WalidatorDowodu{}, "ABA400000", RegulaSumaKontrolna},
+		{"dowód cyfra w serii", WalidatorDowodu{}, "AB1300000", RegulaFormat},
+		{"dowód litera w numerze", WalidatorDowodu{}, "ABA3X0000", RegulaFormat},
+		{"dowód polska litera", WalidatorDowodu{}, "ĄBA300000", RegulaDlugosc},
+		{"dowód za krótki", WalidatorDowodu{}, "ABA30000", RegulaDlugosc},
+	}
+	for _, tt := range testy {
+		t.Run(tt.nazwa, func(t *testing.T) {
+			err := tt.walidator.Weryfikuj(tt.numer)
+			if tt.regula == "" {
+				if err != nil {
+					t.Fatalf("Weryfikuj(%q) = %v, oczekiwano nil", tt.numer, err)
+				}
+				return
+			}
+			var blad *BladIdentyfikatora
+			if !errors.As(err, &blad) {
+				t.Fatalf("Weryfikuj(%q) = %v, oczekiwano *BladIdentyfikatora", tt.numer, err)
+			}
+			if blad.Regula != tt.regula || blad.Identyfikator != tt.walidator.Nazwa() {
+				t.Errorf("Weryfikuj(%q): %s, reguła %q; oczekiwano %s, %q",
+					tt.numer, blad.Identyfikator, blad.Regula, tt.walidator.Nazwa(), tt.regula)
+			}
+		})
+	}
+}
+
+func TestCyfraKontrolnaREGON(t *testing.T) {
+	testy := []struct {
+		cyfry     string
+		kontrolna int
+	}{
+		{"12345678", 5},
+		{"73206581", 4},
+		{"12345616", 0}, // suma ważona daje resztę 10
+	}
+	for _, tt := range testy {
+		cyfry, err := parsujCyfry("REGON", tt.cyfry, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kontrolna := cyfraKontrolnaREGON(cyfry, wagiREGON9); kontrolna != tt.kontrolna {
+			t.Errorf("cyfraKontrolnaREGON(%s) = %d, oczekiwano %d", tt.cyfry, kontrolna, tt.kontrolna)
+		}
+	}
+}
+
+// Każdy wygenerowany numer musi przejść weryfikację tego samego walidatora
+func TestGenerujIdentyfikatory(t *testing.T) {
+	for klucz, walidator := range Walidatory {
+		rnd := rand.New(rand.NewSource(1))
+		for range 10000 {
+			numer := walidator.Generuj(rnd)
+			if err := walidator.Weryfikuj(numer); err != nil {
+				t.Fatalf("%s: Generuj dał %q, który nie przechodzi weryfikacji: %v", klucz, numer, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package pesel
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrPustyKlucz = errors.New("klucz pseudonimizacji nie może być pusty")
+
+// Pseudonimizator zamienia prawdziwe numery PESEL na syntetyczne, poprawne
+// numery z tym samym rokiem urodzenia i płcią. Ten sam klucz zawsze daje
+// ten sam wynik, więc złączenia między tabelami nadal działają. Różne numery
+// mogą rzadko dać ten sam pseudonim (ok. 1,8 mln wartości na rok i płeć).
+type Pseudonimizator struct {
+	klucz []byte
+}
+
+// NowyPseudonimizator tworzy pseudonimizator z tajnym kluczem HMAC
+func NowyPseudonimizator(klucz []byte) (*Pseudonimizator, error) {
+	if len(klucz) == 0 {
+		return nil, ErrPustyKlucz
+	}
+	return &Pseudonimizator{klucz: append([]byte(nil), klucz...)}, nil
+}
+
+// Pseudonimizuj: wyznacza syntetyczny numer PESEL dla prawdziwego numeru
+// Parametry:
+// - pesel: poprawny numer PESEL (separatory są usuwane)
+// Wyjscie:
+// Syntetyczny numer PESEL lub *BladPESEL dla niepoprawnego wejścia
+func (p *Pseudonimizator) Pseudonimizuj(pesel string) (string, error) {
+	pesel = WalidatorPESEL{}.Normalizuj(pesel)
+	if err := WeryfikujPESELTekst(pesel); err != nil {
+		return "", err
+	}
+	dane, _ := DekodujPESELTekst(pesel)
+
+	mac := hmac.New(sha256.New, p.klucz)
+	mac.Write([]byte(pesel))
+	skrot := mac.Sum(nil)
+
+	rok := dane.DataUrodzenia.Year()
+	dniWRoku := time.Date(rok, 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	dzien := int(binary.BigEndian.Uint32(skrot[0:4]) % uint32(dniWRoku))
+	numerSeryjny := int(binary.BigEndian.Uint32(skrot[4:8]) % 1000)
+
+	// cyfra płci zachowuje parzystość oryginału
+	cyfraPlci := int(skrot[8]%5) * 2
+	if dane.Plec == "M" {
+		cyfraPlci++
+	}
+
+	data := time.Date(rok, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, dzien)
+	cyfryPESEL, err := ZbudujPESEL(data, numerSeryjny, cyfraPlci)
+	if err != nil {
+		return "", err
+	}
+	return PESELTekst(cyfryPESEL), nil
+}
+
+// OpcjePrzepisywania określa, które kolumny pliku CSV pseudonimizować
+type OpcjePrzepisywania struct {
+	Kolumny   []int // numery kolumn od 1
+	Separator rune  // domyślnie ','
+	Naglowek  bool  // pierwszy wiersz jest przepisywany bez zmian
+}
+
+// StatystykiPrzepisywania podsumowuje wynik PrzepiszCSV
+type StatystykiPrzepisywania struct {
+	Wiersze     int // wiersze danych (bez nagłówka)
+	Zamienione  int // pola zamienione na pseudonim
+	Niepoprawne int // pola z niepoprawnym numerem, zastąpione pustym tekstem
+}
+
+// PrzepiszCSV: przepisuje plik CSV wiersz po wierszu, zamieniając numery
+// PESEL w wybranych kolumnach na pseudonimy
+// Parametry:
+// - r: źródło danych CSV
+// - w: miejsce zapisu
+// - opcje: kolumny, separator i obsługa nagłówka
+// Wyjscie:
+// Statystyki przepisywania lub błąd odczytu/zapisu
+func (p *Pseudonimizator) PrzepiszCSV(r io.Reader, w io.Writer, opcje OpcjePrzepisywania) (StatystykiPrzepisywania, error) {
+	var statystyki StatystykiPrzepisywania
+	// kolumna podana dwa razy byłaby pseudonimizowana dwukrotnie
+	var kolumny []int
+	widziane := make(map[int]bool)
+	for _, kolumna := range opcje.Kolumny {
+		if kolumna < 1 {
+			return statystyki, fmt.Errorf("niepoprawny numer kolumny: %d", kolumna)
+		}
+		if !widziane[kolumna] {
+			widziane[kolumna] = true
+			kolumny = append(kolumny, kolumna)
+		}
+	}
+	separator := opcje.Separator
+	if separator == 0 {
+		separator = ','
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	writer := csv.NewWriter(w)
+	writer.Comma = separator
+
+	for wiersz := 1; ; wiersz++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return statystyki, fmt.Errorf("błąd odczytu wiersza %d: %w", wiersz, err)
+		}
+
+		if wiersz > 1 || !opcje.Naglowek {
+			statystyki.Wiersze++
+			for _, kolumna := range kolumny {
+				if kolumna > len(record) || record[kolumna-1] == "" {
+					continue
+				}
+				// niepoprawnych numerów nie przepisujemy, żeby nie wyciekły do eksportu
+				pseudonim, err := p.Pseudonimizuj(record[kolumna-1])
+				if err != nil {
+					statystyki.Niepoprawne++
+				} else {
+					statystyki.Zamienione++
+				}
+				record[kolumna-1] = pseudonim
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return statystyki, fmt.Errorf("błąd zapisu wiersza %d: %w", wiersz, err)
+		}
+	}
+
+	writer.Flush()
+	return statystyki, writer.Error()
+}
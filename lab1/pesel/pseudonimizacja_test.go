@@ -0,0 +1,121 @@
+package pesel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// osobyTestowe zwraca numery PESEL z wielu stuleci i obu płci
+func osobyTestowe(t *testing.T) []OsobaPESEL {
+	t.Helper()
+	opcje := OpcjeGenerowania{Od: dzien(1800, 1, 1), Do: dzien(2299, 12, 31), UdzialKobiet: 0.5}
+	osoby, err := NowyGeneratorZZiarnem(5).GenerujWiele(500, opcje)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return osoby
+}
+
+func TestPseudonimizuj(t *testing.T) {
+	p, _ := NowyPseudonimizator([]byte("klucz"))
+	tenSam, _ := NowyPseudonimizator([]byte("klucz"))
+	inny, _ := NowyPseudonimizator([]byte("inny klucz"))
+
+	rozne := 0
+	osoby := osobyTestowe(t)
+	for _, osoba := range osoby {
+		pseudonim, err := p.Pseudonimizuj(osoba.PESEL)
+		if err != nil {
+			t.Fatalf("Pseudonimizuj(%s): %v", osoba.PESEL, err)
+		}
+		if err := WeryfikujPESELTekst(pseudonim); err != nil {
+			t.Errorf("Pseudonimizuj(%s) = %s, niepoprawny numer: %v", osoba.PESEL, pseudonim, err)
+			continue
+		}
+		oryginal, _ := DekodujPESELTekst(osoba.PESEL)
+		dane, _ := DekodujPESELTekst(pseudonim)
+		if dane.DataUrodzenia.Year() != oryginal.DataUrodzenia.Year() || dane.Plec != oryginal.Plec {
+			t.Errorf("Pseudonimizuj(%s) = %s: rok %d, płeć %s; oczekiwano %d, %s", osoba.PESEL, pseudonim,
+				dane.DataUrodzenia.Year(), dane.Plec, oryginal.DataUrodzenia.Year(), oryginal.Plec)
+		}
+
+		if ponownie, _ := tenSam.Pseudonimizuj(osoba.PESEL); ponownie != pseudonim {
+			t.Errorf("Pseudonimizuj(%s) z tym samym kluczem dał %s i %s", osoba.PESEL, pseudonim, ponownie)
+		}
+		if zInnym, _ := inny.Pseudonimizuj(osoba.PESEL); zInnym != pseudonim {
+			rozne++
+		}
+	}
+	// przypadkowa zgodność jest możliwa, ale bardzo rzadka
+	if rozne < len(osoby)-1 {
+		t.Errorf("inny klucz zmienił tylko %d z %d pseudonimów", rozne, len(osoby))
+	}
+}
+
+func TestPseudonimizujBledy(t *testing.T) {
+	if _, err := NowyPseudonimizator(nil); !errors.Is(err, ErrPustyKlucz) {
+		t.Errorf("NowyPseudonimizator(nil) = %v, oczekiwano %v", err, ErrPustyKlucz)
+	}
+	p, _ := NowyPseudonimizator([]byte("klucz"))
+	if pseudonim, err := p.Pseudonimizuj("44051401358"); !errors.Is(err, ErrNiepoprawnaCyfraKontrolna) || pseudonim != "" {
+		t.Errorf("Pseudonimizuj(44051401358) = %q, %v; oczekiwano błędu sumy kontrolnej", pseudonim, err)
+	}
+}
+
+func TestPrzepiszCSV(t *testing.T) {
+	p, _ := NowyPseudonimizator([]byte("klucz"))
+	pseudonim := func(numer string) string {
+		wynik, err := p.Pseudonimizuj(numer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wynik
+	}
+
+	wejscie := "imie;pesel;opiekun\n" +
+		"Jan;44051401359;02070803628\n" +
+		"Ewa;440514013XX;\n" +
+		"Anna\n" +
+		"Piotr;90090515836;90090515843\n"
+	var wyjscie bytes.Buffer
+	// kolumna 2 podana dwa razy musi zostać zamieniona tylko raz
+	statystyki, err := p.PrzepiszCSV(strings.NewReader(wejscie), &wyjscie,
+		OpcjePrzepisywania{Kolumny: []int{2, 3, 2}, Separator: ';', Naglowek: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oczekiwane := StatystykiPrzepisywania{Wiersze: 4, Zamienione: 4, Niepoprawne: 1}
+	if statystyki != oczekiwane {
+		t.Errorf("statystyki %+v, oczekiwano %+v", statystyki, oczekiwane)
+	}
+
+	reader := csv.NewReader(&wyjscie)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+	wiersze, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oczekiwaneWiersze := [][]string{
+		{"imie", "pesel", "opiekun"},
+		{"Jan", pseudonim("44051401359"), pseudonim("02070803628")},
+		{"Ewa", "", ""},
+		{"Anna"},
+		{"Piotr", pseudonim("90090515836"), pseudonim("90090515843")},
+	}
+	if len(wiersze) != len(oczekiwaneWiersze) {
+		t.Fatalf("zapisano %d wierszy, oczekiwano %d: %q", len(wiersze), len(oczekiwaneWiersze), wiersze)
+	}
+	for i := range wiersze {
+		if strings.Join(wiersze[i], ";") != strings.Join(oczekiwaneWiersze[i], ";") {
+			t.Errorf("wiersz %d: %q, oczekiwano %q", i+1, wiersze[i], oczekiwaneWiersze[i])
+		}
+	}
+
+	if _, err := p.PrzepiszCSV(strings.NewReader(wejscie), &wyjscie, OpcjePrzepisywania{Kolumny: []int{0}}); err == nil {
+		t.Error("PrzepiszCSV z kolumną 0 nie zwrócił błędu")
+	}
+}
@@ -0,0 +1,72 @@
+package pesel
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Regula określa, który warunek poprawności numeru PESEL nie został spełniony
+type Regula string
+
+const (
+	RegulaDlugosc       Regula = "dlugosc"
+	RegulaCyfry         Regula = "cyfry"
+	RegulaData          Regula = "data"
+	RegulaSumaKontrolna Regula = "suma_kontrolna"
+)
+
+var ErrNiepoprawnaCyfraKontrolna = errors.New("niepoprawna cyfra kontrolna")
+
+// BladPESEL jest zwracany przez WeryfikujPESEL i wskazuje naruszoną regułę.
+// Powod to pierwotny błąd (np. *BladDaty albo ErrNiepoprawnaCyfraKontrolna).
+type BladPESEL struct {
+	Regula Regula
+	Powod  error
+}
+
+func (e *BladPESEL) Error() string {
+	return fmt.Sprintf("PESEL niepoprawny (%s): %v", e.Regula, e.Powod)
+}
+
+func (e *BladPESEL) Unwrap() error { return e.Powod }
+
+// WeryfikujPESEL: weryfikuje poprawność numeru PESEL
+// Parametry:
+// - cyfryPESEL: Tablica z cyframi numeru PESEL
+// Wyjscie:
+// nil dla poprawnego numeru, w przeciwnym razie *BladPESEL
+func WeryfikujPESEL(cyfryPESEL [11]int) error {
+	for _, cyfra := range cyfryPESEL {
+		if cyfra < 0 || cyfra > 9 {
+			return &BladPESEL{Regula: RegulaCyfry, Powod: ErrNiepoprawnaCyfra}
+		}
+	}
+
+	if _, err := DekodujPESEL(cyfryPESEL); err != nil {
+		return &BladPESEL{Regula: RegulaData, Powod: err}
+	}
+
+	var cyfry [10]int
+	copy(cyfry[:], cyfryPESEL[:10])
+	if ObliczCyfre(cyfry) != cyfryPESEL[10] {
+		return &BladPESEL{Regula: RegulaSumaKontrolna, Powod: ErrNiepoprawnaCyfraKontrolna}
+	}
+
+	return nil
+}
+
+// WeryfikujPESELTekst: weryfikuje numer PESEL podany jako tekst
+// Parametry:
+// - tekst: numer PESEL
+// Wyjscie:
+// nil dla poprawnego numeru, w przeciwnym razie *BladPESEL
+func WeryfikujPESELTekst(tekst string) error {
+	cyfryPESEL, err := ParsujPESEL(tekst)
+	if errors.Is(err, ErrNiepoprawnaDlugosc) {
+		return &BladPESEL{Regula: RegulaDlugosc, Powod: err}
+	}
+	if err != nil {
+		return &BladPESEL{Regula: RegulaCyfry, Powod: err}
+	}
+	return WeryfikujPESEL(cyfryPESEL)
+}
@@ -0,0 +1,89 @@
+package pesel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWeryfikujPESELTekst(t *testing.T) {
+	testy := []struct {
+		nazwa  string
+		numer  string
+		regula Regula // pusta dla poprawnego numeru
+		powod  error
+	}{
+		{"poprawny mężczyzna 1944", "44051401359", "", nil},
+		{"poprawna kobieta 1902", "02070803628", "", nil},
+		{"poprawna kobieta 1990", "90090515843", "", nil},
+		{"poprawny mężczyzna 1990", "90090515836", "", nil},
+		{"za krótki", "4405140135", RegulaDlugosc, ErrNiepoprawnaDlugosc},
+		{"za długi", "440514013590", RegulaDlugosc, ErrNiepoprawnaDlugosc},
+		{"pusty", "", RegulaDlugosc, ErrNiepoprawnaDlugosc},
+		{"litera", "4405140135X", RegulaCyfry, ErrNiepoprawnaCyfra},
+		{"spacja", "44051 01359", RegulaCyfry, ErrNiepoprawnaCyfra},
+		{"miesiąc 13", "44131401359", RegulaData, ErrNiepoprawnyMiesiac},
+		{"miesiąc 00", "44001401359", RegulaData, ErrNiepoprawnyMiesiac},
+		{"31 kwietnia", "44043101359", RegulaData, ErrNiepoprawnyDzien},
+		{"29 lutego w roku nieprzestępnym", "23022901359", RegulaData, ErrNiepoprawnyDzien},
+		{"zła cyfra kontrolna", "44051401358", RegulaSumaKontrolna, ErrNiepoprawnaCyfraKontrolna},
+		{"zła cyfra kontrolna kobiety", "02070803629", RegulaSumaKontrolna, ErrNiepoprawnaCyfraKontrolna},
+	}
+	for _, tt := range testy {
+		t.Run(tt.nazwa, func(t *testing.T) {
+			err := WeryfikujPESELTekst(tt.numer)
+			if tt.regula == "" {
+				if err != nil {
+					t.Fatalf("WeryfikujPESELTekst(%q) = %v, oczekiwano nil", tt.numer, err)
+				}
+				return
+			}
+			var blad *BladPESEL
+			if !errors.As(err, &blad) {
+				t.Fatalf("WeryfikujPESELTekst(%q) = %v, oczekiwano *BladPESEL", tt.numer, err)
+			}
+			if blad.Regula != tt.regula {
+				t.Errorf("WeryfikujPESELTekst(%q): reguła %q, oczekiwano %q", tt.numer, blad.Regula, tt.regula)
+			}
+			if !errors.Is(err, tt.powod) {
+				t.Errorf("WeryfikujPESELTekst(%q) = %v, oczekiwano błędu %v", tt.numer, err, tt.powod)
+			}
+		})
+	}
+}
+
+// Cyfry spoza zakresu 0-9 da się podać tylko jako tablicę, z pominięciem ParsujPESEL
+func TestWeryfikujPESELCyfraPozaZakresem(t *testing.T) {
+	for _, cyfryPESEL := range [][11]int{
+		{4, 4, 0, 5, 1, 4, 0, 1, 3, 5, 10},
+		{4, 4, 0, 5, 1, 4, 0, 1, 3, -1, 9},
+	} {
+		var blad *BladPESEL
+		err := WeryfikujPESEL(cyfryPESEL)
+		if !errors.As(err, &blad) || blad.Regula != RegulaCyfry {
+			t.Errorf("WeryfikujPESEL(%v) = %v, oczekiwano reguły %q", cyfryPESEL, err, RegulaCyfry)
+		}
+	}
+}
+
+func TestDekodujPESELPlec(t *testing.T) {
+	testy := []struct {
+		numer string
+		plec  string
+		data  string
+	}{
+		{"44051401359", "M", "1944-05-14"},
+		{"02070803628", "K", "1902-07-08"},
+		{"90090515843", "K", "1990-09-05"},
+		{"90090515836", "M", "1990-09-05"},
+	}
+	for _, tt := range testy {
+		dane, err := DekodujPESELTekst(tt.numer)
+		if err != nil {
+			t.Fatalf("DekodujPESELTekst(%q): %v", tt.numer, err)
+		}
+		if dane.Plec != tt.plec || dane.DataUrodzenia.Format("2006-01-02") != tt.data {
+			t.Errorf("DekodujPESELTekst(%q) = %s %s, oczekiwano %s %s",
+				tt.numer, dane.Plec, dane.DataUrodzenia.Format("2006-01-02"), tt.plec, tt.data)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package pesel generuje, weryfikuje, dekoduje i pseudonimizuje numery PESEL
+// oraz weryfikuje inne polskie identyfikatory (NIP, REGON, dowód osobisty).
+// Z pakietu korzystają komenda pesel i jej serwer HTTP z katalogu lab1.
+package pesel
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Zakres lat, dla których PoliczMiesiac zna przesunięcie stulecia
+const (
+	MinRokPESEL = 1800
+	MaxRokPESEL = 2299
+)
+
+var (
+	ErrRokPozaZakresem         = errors.New("rok urodzenia poza zakresem 1800-2299")
+	ErrNiepoprawnyNumerSeryjny = errors.New("numer seryjny musi być z zakresu 000-999")
+	ErrNiepoprawnaCyfraPlci    = errors.New("cyfra płci musi być z zakresu 0-9")
+)
+
+// GeneratePESEL: geneuje numer PESEL
+// Parametry:
+// - birthDate: time.Time: reprezentacja daty urodzenia (lata 1800-2299)
+// - płeć: znak "M" lub "K"
+// Wyjscie:
+// Tablica z cyframi numeru PESEL lub ErrRokPozaZakresem
+func GenerujPESEL(birthDate time.Time, gender string) ([11]int, error) {
+	return generujPESEL(rand.Intn, birthDate, gender)
+}
+
+// generujPESEL buduje numer PESEL, losując liczby funkcją losuj
+// (rand.Intn albo metodą Intn konkretnego *rand.Rand)
+func generujPESEL(losuj func(int) int, birthDate time.Time, gender string) ([11]int, error) {
+
+	// losowy numer z pełnego zakresu 000-999
+	randomSerial := losuj(1000)
+
+	// cyfra płci: nieparzysta dla mężczyzn, parzysta dla kobiet
+	var genderNum int
+	if gender == "M" {
+		genderNum = losuj(5)*2 + 1
+	} else {
+		genderNum = losuj(5) * 2
+	}
+
+	return ZbudujPESEL(birthDate, randomSerial, genderNum)
+}
+
+// ZbudujPESEL: składa numer PESEL z podanych części
+// Parametry:
+// - birthDate: data urodzenia (lata 1800-2299)
+// - numerSeryjny: cyfry 7-9 numeru, z zakresu 000-999
+// - cyfraPlci: cyfra 10, nieparzysta dla mężczyzn, parzysta dla kobiet
+// Wyjscie:
+// Tablica z cyframi numeru PESEL lub błąd
+func ZbudujPESEL(birthDate time.Time, numerSeryjny int, cyfraPlci int) ([11]int, error) {
+	var cyfryPESEL [11]int
+
+	year := birthDate.Year()
+	if year < MinRokPESEL || year > MaxRokPESEL {
+		return cyfryPESEL, ErrRokPozaZakresem
+	}
+	if numerSeryjny < 0 || numerSeryjny > 999 {
+		return cyfryPESEL, ErrNiepoprawnyNumerSeryjny
+	}
+	if cyfraPlci < 0 || cyfraPlci > 9 {
+		return cyfryPESEL, ErrNiepoprawnaCyfraPlci
+	}
+
+	// kazda czesc jest zapisywana na stalej liczbie cyfr z zerami wiodacymi
+	month := PoliczMiesiac(int(birthDate.Month()), year)
+	day := birthDate.Day()
+	yearArr := cyfryZZerami(year%100, 2)
+	monthArr := cyfryZZerami(month, 2)
+	dayArr := cyfryZZerami(day, 2)
+	serialArr := cyfryZZerami(numerSeryjny, 3)
+
+	cyfry := [10]int{yearArr[0], yearArr[1], monthArr[0], monthArr[1], dayArr[0], dayArr[1], serialArr[0], serialArr[1], serialArr[2], cyfraPlci}
+	copy(cyfryPESEL[:], cyfry[:])
+	cyfryPESEL[10] = ObliczCyfre(cyfry)
+
+	return cyfryPESEL, nil
+}
+
+// cyfryZZerami zwraca cyfry liczby n uzupełnione zerami z przodu do dlugosc
+func cyfryZZerami(n int, dlugosc int) []int {
+	arr := LiczbaDoListy(n)
+	for len(arr) < dlugosc {
+		arr = append([]int{0}, arr...)
+	}
+	return arr
+}
+
+func PoliczMiesiac(month int, year int) int {
+	if year < 1900 {
+		return month + 80
+	} else if year < 2000 {
+		return month
+	} else if year < 2100 {
+		return month + 20
+	} else if year < 2200 {
+		return month + 40
+	} else if year < 2300 {
+		return month + 60
+	} else {
+		return month
+	}
+}
+
+func LiczbaDoListy(n int) []int {
+	str := strconv.Itoa(n)
+	arr := make([]int, len(str))
+
+	for i, char := range str {
+		arr[i] = int(char - '0')
+	}
+	return arr
+}
+
+func ObliczCyfre(cyfry [10]int) int {
+	num1 := Liczba(int(cyfry[0]), 1)
+	num2 := Liczba(int(cyfry[1]), 3)
+	num3 := Liczba(int(cyfry[2]), 7)
+	num4 := Liczba(int(cyfry[3]), 9)
+	num5 := Liczba(int(cyfry[4]), 1)
+	num6 := Liczba(int(cyfry[5]), 3)
+	num7 := Liczba(int(cyfry[6]), 7)
+	num8 := Liczba(int(cyfry[7]), 9)
+	num9 := Liczba(int(cyfry[8]), 1)
+	num10 := Liczba(int(cyfry[9]), 3)
+	sum := num1 + num2 + num3 + num4 + num5 + num6 + num7 + num8 + num9 + num10
+	// cyfra kontrolna to dopełnienie ostatniej cyfry sumy do 10 (0 gdy suma kończy się zerem)
+	return (10 - sum%10) % 10
+}
+
+func Liczba(liczba int, mnoznik int) int {
+	num := liczba * mnoznik
+	if num >= 10 {
+		num = num % 10
+	}
+	return num
+}
@@ -0,0 +1,112 @@
+package pesel
+
+import (
+	"errors"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// sprawdzZbudowany buduje numer z podanych części i sprawdza, że przechodzi
+// weryfikację, a dekodowanie zwraca te same datę, płeć i numer seryjny
+func sprawdzZbudowany(t *testing.T, data time.Time, numerSeryjny, cyfraPlci int) bool {
+	t.Helper()
+	cyfryPESEL, err := ZbudujPESEL(data, numerSeryjny, cyfraPlci)
+	if err != nil {
+		t.Errorf("ZbudujPESEL(%s, %03d, %d): %v", data.Format("2006-01-02"), numerSeryjny, cyfraPlci, err)
+		return false
+	}
+	if err := WeryfikujPESEL(cyfryPESEL); err != nil {
+		t.Errorf("WeryfikujPESEL(%s) dla %s: %v", PESELTekst(cyfryPESEL), data.Format("2006-01-02"), err)
+		return false
+	}
+	dane, err := DekodujPESEL(cyfryPESEL)
+	if err != nil {
+		t.Errorf("DekodujPESEL(%s): %v", PESELTekst(cyfryPESEL), err)
+		return false
+	}
+	plec := "K"
+	if cyfraPlci%2 == 1 {
+		plec = "M"
+	}
+	if !dane.ZgodnyZData(data) || dane.Plec != plec || dane.NumerSeryjny != numerSeryjny ||
+		dane.Stulecie != data.Year()/100*100 {
+		t.Errorf("DekodujPESEL(%s) = %s %s %03d %d, oczekiwano %s %s %03d",
+			PESELTekst(cyfryPESEL), dane.DataUrodzenia.Format("2006-01-02"), dane.Plec, dane.NumerSeryjny,
+			dane.Stulecie, data.Format("2006-01-02"), plec, numerSeryjny)
+		return false
+	}
+	return true
+}
+
+// TestZbudujPESELKazdyDzien sprawdza każdy dzień lat 1800-2299. Numer seryjny
+// i cyfra płci zmieniają się z dnia na dzień, tak aby w całym zakresie
+// wystąpiła każda z 10 000 ich kombinacji.
+func TestZbudujPESELKazdyDzien(t *testing.T) {
+	koniec := time.Date(MaxRokPESEL+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	i := 0
+	for data := time.Date(MinRokPESEL, 1, 1, 0, 0, 0, 0, time.UTC); data.Before(koniec); data = data.AddDate(0, 0, 1) {
+		if !sprawdzZbudowany(t, data, i%1000, i/1000%10) {
+			return
+		}
+		i++
+	}
+}
+
+// TestZbudujPESELLosowo sprawdza losowe daty, numery seryjne i cyfry płci
+func TestZbudujPESELLosowo(t *testing.T) {
+	poczatek := time.Date(MinRokPESEL, 1, 1, 0, 0, 0, 0, time.UTC)
+	dni := int(time.Date(MaxRokPESEL, 12, 31, 0, 0, 0, 0, time.UTC).Sub(poczatek).Hours()/24) + 1
+	wlasnosc := func(dzien uint32, numerSeryjny uint16, cyfraPlci uint8) bool {
+		data := poczatek.AddDate(0, 0, int(dzien%uint32(dni)))
+		return sprawdzZbudowany(t, data, int(numerSeryjny%1000), int(cyfraPlci%10))
+	}
+	if err := quick.Check(wlasnosc, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestZbudujPESELBledy(t *testing.T) {
+	data := time.Date(1990, 9, 5, 0, 0, 0, 0, time.UTC)
+	testy := []struct {
+		nazwa        string
+		data         time.Time
+		numerSeryjny int
+		cyfraPlci    int
+		blad         error
+	}{
+		{"rok 1799", time.Date(1799, 12, 31, 0, 0, 0, 0, time.UTC), 0, 0, ErrRokPozaZakresem},
+		{"rok 2300", time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC), 0, 0, ErrRokPozaZakresem},
+		{"numer seryjny -1", data, -1, 0, ErrNiepoprawnyNumerSeryjny},
+		{"numer seryjny 1000", data, 1000, 0, ErrNiepoprawnyNumerSeryjny},
+		{"cyfra płci -1", data, 0, -1, ErrNiepoprawnaCyfraPlci},
+		{"cyfra płci 10", data, 0, 10, ErrNiepoprawnaCyfraPlci},
+	}
+	for _, tt := range testy {
+		if _, err := ZbudujPESEL(tt.data, tt.numerSeryjny, tt.cyfraPlci); !errors.Is(err, tt.blad) {
+			t.Errorf("%s: błąd %v, oczekiwano %v", tt.nazwa, err, tt.blad)
+		}
+	}
+}
+
+func TestGenerujPESEL(t *testing.T) {
+	for _, plec := range []string{"M", "K"} {
+		data := time.Date(2005, 3, 17, 0, 0, 0, 0, time.UTC)
+		cyfryPESEL, err := GenerujPESEL(data, plec)
+		if err != nil {
+			t.Fatalf("GenerujPESEL(%s, %s): %v", data.Format("2006-01-02"), plec, err)
+		}
+		dane, err := DekodujPESEL(cyfryPESEL)
+		if err != nil || dane.Plec != plec || !dane.ZgodnyZData(data) {
+			t.Errorf("GenerujPESEL(%s, %s) = %s, dekodowany jako %+v, %v",
+				data.Format("2006-01-02"), plec, PESELTekst(cyfryPESEL), dane, err)
+		}
+	}
+
+	for _, rok := range []int{MinRokPESEL - 1, MaxRokPESEL + 1} {
+		data := time.Date(rok, 1, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := GenerujPESEL(data, "M"); !errors.Is(err, ErrRokPozaZakresem) {
+			t.Errorf("GenerujPESEL(%s): błąd %v, oczekiwano %v", data.Format("2006-01-02"), err, ErrRokPozaZakresem)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"lab1/pesel"
+)
+
+// Limity chroniące serwer przed zbyt dużymi zapytaniami
+const (
+	maksRozmiarZadania = 1 << 20 // 1 MiB treści zapytania
+	maksLiczbaNumerow  = 1000    // numerów w jednym zapytaniu walidacji lub generowania
+)
+
+// Kody błędów zwracane w polu "kod" obok reguł walidacji (dlugosc, cyfry, data, suma_kontrolna)
+const (
+	kodBlednyJSON      = "bledny_json"
+	kodZaDuzeZadanie   = "za_duze_zadanie"
+	kodBledneParametry = "bledne_parametry"
+)
+
+type zadanieWalidacji struct {
+	PESEL  *string  `json:"pesel,omitempty"`
+	PESELe []string `json:"pesele,omitempty"`
+}
+
+type wynikWalidacji struct {
+	PESEL    string       `json:"pesel"`
+	Poprawny bool         `json:"poprawny"`
+	Kod      pesel.Regula `json:"kod,omitempty"`
+	Blad     string       `json:"blad,omitempty"`
+}
+
+type odpowiedzWalidacji struct {
+	Wyniki      []wynikWalidacji `json:"wyniki"`
+	Poprawne    int              `json:"poprawne"`
+	Niepoprawne int              `json:"niepoprawne"`
+}
+
+type odpowiedzDekodowania struct {
+	PESEL         string `json:"pesel"`
+	DataUrodzenia string `json:"data_urodzenia"`
+	Stulecie      int    `json:"stulecie"`
+	Plec          string `json:"plec"`
+	NumerSeryjny  int    `json:"numer_seryjny"`
+}
+
+type zadanieGenerowania struct {
+	Od     string `json:"od"`
+	Do     string `json:"do"`
+	Plec   string `json:"plec"`
+	N      int    `json:"n"`
+	Ziarno int64  `json:"ziarno"`
+}
+
+type odpowiedzBledu struct {
+	Kod  string `json:"kod"`
+	Blad string `json:"blad"`
+}
+
+// NowySerwer zwraca handler HTTP udostępniający walidację, dekodowanie
+// i generowanie numerów PESEL
+func NowySerwer() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /pesel/validate", obsluzWalidacje)
+	mux.HandleFunc("GET /pesel/decode/{numer}", obsluzDekodowanie)
+	mux.HandleFunc("POST /pesel/generate", obsluzGenerowanie)
+	return mux
+}
+
+func obsluzWalidacje(w http.ResponseWriter, r *http.Request) {
+	var zadanie zadanieWalidacji
+	if !czytajJSON(w, r, &zadanie) {
+		return
+	}
+
+	numery := zadanie.PESELe
+	pojedynczy := zadanie.PESEL != nil
+	if pojedynczy {
+		numery = append(numery, *zadanie.PESEL)
+	}
+	if len(numery) == 0 || len(numery) > maksLiczbaNumerow || (pojedynczy && len(zadanie.PESELe) > 0) {
+		zapiszBlad(w, http.StatusBadRequest, kodBledneParametry,
+			fmt.Sprintf("podaj pole \"pesel\" albo od 1 do %d numerów w polu \"pesele\"", maksLiczbaNumerow))
+		return
+	}
+
+	var odpowiedz odpowiedzWalidacji
+	for _, numer := range numery {
+		wynik := wynikWalidacji{PESEL: numer, Poprawny: true}
+		if err := (pesel.WalidatorPESEL{}).Weryfikuj(numer); err != nil {
+			wynik = wynikWalidacji{PESEL: numer, Kod: regulaBledu(err), Blad: err.Error()}
+			odpowiedz.Niepoprawne++
+		} else {
+			odpowiedz.Poprawne++
+		}
+		odpowiedz.Wyniki = append(odpowiedz.Wyniki, wynik)
+	}
+
+	if pojedynczy {
+		zapiszJSON(w, http.StatusOK, odpowiedz.Wyniki[0])
+		return
+	}
+	zapiszJSON(w, http.StatusOK, odpowiedz)
+}
+
+func obsluzDekodowanie(w http.ResponseWriter, r *http.Request) {
+	numer := pesel.WalidatorPESEL{}.Normalizuj(r.PathValue("numer"))
+	if err := pesel.WeryfikujPESELTekst(numer); err != nil {
+		zapiszBlad(w, http.StatusUnprocessableEntity, string(regulaBledu(err)), err.Error())
+		return
+	}
+	dane, _ := pesel.DekodujPESELTekst(numer)
+	zapiszJSON(w, http.StatusOK, odpowiedzDekodowania{
+		PESEL:         numer,
+		DataUrodzenia: dane.DataUrodzenia.Format("2006-01-02"),
+		Stulecie:      dane.Stulecie,
+		Plec:          dane.Plec,
+		NumerSeryjny:  dane.NumerSeryjny,
+	})
+}
+
+func obsluzGenerowanie(w http.ResponseWriter, r *http.Request) {
+	zadanie := zadanieGenerowania{Od: "1950-01-01", Do: "2005-12-31", N: 1}
+	if !czytajJSON(w, r, &zadanie) {
+		return
+	}
+
+	opcje := pesel.OpcjeGenerowania{UdzialKobiet: 0.5}
+	var errOd, errDo error
+	opcje.Od, errOd = time.Parse("2006-01-02", zadanie.Od)
+	opcje.Do, errDo = time.Parse("2006-01-02", zadanie.Do)
+	if err := errors.Join(errOd, errDo); err != nil {
+		zapiszBlad(w, http.StatusBadRequest, kodBledneParametry, err.Error())
+		return
+	}
+	switch zadanie.Plec {
+	case "M":
+		opcje.UdzialKobiet = 0
+	case "K":
+		opcje.UdzialKobiet = 1
+	case "":
+	default:
+		zapiszBlad(w, http.StatusBadRequest, kodBledneParametry, "pole \"plec\" musi mieć wartość M lub K")
+		return
+	}
+	if zadanie.N < 1 || zadanie.N > maksLiczbaNumerow {
+		zapiszBlad(w, http.StatusBadRequest, kodBledneParametry, fmt.Sprintf("pole \"n\" musi być z zakresu 1-%d", maksLiczbaNumerow))
+		return
+	}
+	if zadanie.Ziarno == 0 {
+		zadanie.Ziarno = time.Now().UnixNano()
+	}
+
+	osoby, err := pesel.NowyGeneratorZZiarnem(zadanie.Ziarno).GenerujWiele(zadanie.N, opcje)
+	if err != nil {
+		zapiszBlad(w, http.StatusBadRequest, kodBledneParametry, err.Error())
+		return
+	}
+	zapiszJSON(w, http.StatusOK, osoby)
+}
+
+// czytajJSON dekoduje treść zapytania z limitem rozmiaru; przy błędzie
+// sam wysyła odpowiedź i zwraca false
+func czytajJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maksRozmiarZadania)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var zaDuze *http.MaxBytesError
+		if errors.As(err, &zaDuze) {
+			zapiszBlad(w, http.StatusRequestEntityTooLarge, kodZaDuzeZadanie,
+				fmt.Sprintf("treść zapytania przekracza %d bajtów", zaDuze.Limit))
+			return false
+		}
+		zapiszBlad(w, http.StatusBadRequest, kodBlednyJSON, err.Error())
+		return false
+	}
+	return true
+}
+
+func zapiszJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func zapiszBlad(w http.ResponseWriter, status int, kod, komunikat string) {
+	zapiszJSON(w, status, odpowiedzBledu{Kod: kod, Blad: komunikat})
+}
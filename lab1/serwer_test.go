@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lab1/pesel"
+)
+
+// wyslij wysyła zapytanie do serwera i dekoduje odpowiedź JSON do v
+func wyslij(t *testing.T, metoda, sciezka, tresc string, v any) int {
+	t.Helper()
+	zadanie := httptest.NewRequest(metoda, sciezka, strings.NewReader(tresc))
+	nagrywarka := httptest.NewRecorder()
+	NowySerwer().ServeHTTP(nagrywarka, zadanie)
+	if typ := nagrywarka.Header().Get("Content-Type"); !strings.HasPrefix(typ, "application/json") {
+		t.Fatalf("%s %s: Content-Type %q, oczekiwano JSON", metoda, sciezka, typ)
+	}
+	if err := json.Unmarshal(nagrywarka.Body.Bytes(), v); err != nil {
+		t.Fatalf("%s %s: niepoprawny JSON %q: %v", metoda, sciezka, nagrywarka.Body.String(), err)
+	}
+	return nagrywarka.Code
+}
+
+func TestWalidacjaPojedyncza(t *testing.T) {
+	testy := []struct {
+		pesel    string
+		poprawny bool
+		kod      pesel.Regula
+	}{
+		{"44051401359", true, ""},
+		{"02070803628", true, ""},
+		{"44051401358", false, pesel.RegulaSumaKontrolna},
+		{"4405140135", false, pesel.RegulaDlugosc},
+		{"44131401359", false, pesel.RegulaData},
+	}
+	for _, tt := range testy {
+		var wynik wynikWalidacji
+		status := wyslij(t, "POST", "/pesel/validate", fmt.Sprintf(`{"pesel": %q}`, tt.pesel), &wynik)
+		if status != http.StatusOK {
+			t.Fatalf("%s: status %d, oczekiwano 200", tt.pesel, status)
+		}
+		if wynik.PESEL != tt.pesel || wynik.Poprawny != tt.poprawny || wynik.Kod != tt.kod {
+			t.Errorf("%s: wynik %+v, oczekiwano poprawny=%v kod=%q", tt.pesel, wynik, tt.poprawny, tt.kod)
+		}
+		if !tt.poprawny && wynik.Blad == "" {
+			t.Errorf("%s: brak opisu błędu", tt.pesel)
+		}
+	}
+}
+
+func TestWalidacjaWielu(t *testing.T) {
+	var odpowiedz odpowiedzWalidacji
+	status := wyslij(t, "POST", "/pesel/validate",
+		`{"pesele": ["44051401359", "02070803628", "4405140135X"]}`, &odpowiedz)
+	if status != http.StatusOK {
+		t.Fatalf("status %d, oczekiwano 200", status)
+	}
+	if odpowiedz.Poprawne != 2 || odpowiedz.Niepoprawne != 1 || len(odpowiedz.Wyniki) != 3 {
+		t.Fatalf("odpowiedź %+v, oczekiwano 2 poprawnych i 1 niepoprawnego", odpowiedz)
+	}
+	if odpowiedz.Wyniki[2].Kod != pesel.RegulaCyfry {
+		t.Errorf("kod trzeciego numeru %q, oczekiwano %q", odpowiedz.Wyniki[2].Kod, pesel.RegulaCyfry)
+	}
+}
+
+func TestWalidacjaBledneZadanie(t *testing.T) {
+	zaDuzo := `{"pesele": [` + strings.Repeat(`"44051401359",`, maksLiczbaNumerow) + `"44051401359"]}`
+	testy := []struct {
+		nazwa  string
+		tresc  string
+		status int
+		kod    string
+	}{
+		{"brak numerów", `{}`, http.StatusBadRequest, kodBledneParametry},
+		{"pusta lista", `{"pesele": []}`, http.StatusBadRequest, kodBledneParametry},
+		{"oba pola", `{"pesel": "44051401359", "pesele": ["02070803628"]}`, http.StatusBadRequest, kodBledneParametry},
+		{"za dużo numerów", zaDuzo, http.StatusBadRequest, kodBledneParametry},
+		{"nieznane pole", `{"numer": "44051401359"}`, http.StatusBadRequest, kodBlednyJSON},
+		{"niepoprawny JSON", `{"pesel": `, http.StatusBadRequest, kodBlednyJSON},
+		{"za duża treść", `{"pesel": "` + strings.Repeat("1", maksRozmiarZadania) + `"}`,
+			http.StatusRequestEntityTooLarge, kodZaDuzeZadanie},
+	}
+	for _, tt := range testy {
+		var blad odpowiedzBledu
+		status := wyslij(t, "POST", "/pesel/validate", tt.tresc, &blad)
+		if status != tt.status || blad.Kod != tt.kod {
+			t.Errorf("%s: status %d, kod %q; oczekiwano %d, %q", tt.nazwa, status, blad.Kod, tt.status, tt.kod)
+		}
+	}
+}
+
+func TestDekodowanie(t *testing.T) {
+	var dane odpowiedzDekodowania
+	if status := wyslij(t, "GET", "/pesel/decode/02070803628", "", &dane); status != http.StatusOK {
+		t.Fatalf("status %d, oczekiwano 200", status)
+	}
+	oczekiwane := odpowiedzDekodowania{PESEL: "02070803628", DataUrodzenia: "1902-07-08", Stulecie: 1900, Plec: "K", NumerSeryjny: 36}
+	if dane != oczekiwane {
+		t.Errorf("odpowiedź %+v, oczekiwano %+v", dane, oczekiwane)
+	}
+
+	testy := []struct {
+		numer string
+		kod   pesel.Regula
+	}{
+		{"44051401358", pesel.RegulaSumaKontrolna},
+		{"44131401359", pesel.RegulaData},
+		{"440514", pesel.RegulaDlugosc},
+		{"4405140135X", pesel.RegulaCyfry},
+	}
+	for _, tt := range testy {
+		var blad odpowiedzBledu
+		status := wyslij(t, "GET", "/pesel/decode/"+tt.numer, "", &blad)
+		if status != http.StatusUnprocessableEntity || blad.Kod != string(tt.kod) {
+			t.Errorf("%s: status %d, kod %q; oczekiwano 422, %q", tt.numer, status, blad.Kod, tt.kod)
+		}
+	}
+}
+
+func TestGenerowanie(t *testing.T) {
+	var osoby []pesel.OsobaPESEL
+	status := wyslij(t, "POST", "/pesel/generate",
+		fmt.Sprintf(`{"od": "2000-01-01", "do": "2000-12-31", "plec": "K", "n": %d, "ziarno": 3}`, maksLiczbaNumerow), &osoby)
+	if status != http.StatusOK {
+		t.Fatalf("status %d, oczekiwano 200", status)
+	}
+	if len(osoby) != maksLiczbaNumerow {
+		t.Fatalf("wygenerowano %d numerów, oczekiwano %d", len(osoby), maksLiczbaNumerow)
+	}
+	for _, osoba := range osoby {
+		if err := pesel.WeryfikujPESELTekst(osoba.PESEL); err != nil || osoba.Plec != "K" ||
+			!strings.HasPrefix(osoba.DataUrodzenia, "2000-") {
+			t.Fatalf("niepoprawna osoba %+v: %v", osoba, err)
+		}
+	}
+
+	testy := []struct {
+		nazwa string
+		tresc string
+	}{
+		{"n równe 0", `{"n": 0}`},
+		{"n ujemne", `{"n": -1}`},
+		{"n ponad limit", fmt.Sprintf(`{"n": %d}`, maksLiczbaNumerow+1)},
+		{"rok poza zakresem", `{"od": "1799-12-31", "do": "1800-01-31"}`},
+		{"odwrócony zakres", `{"od": "2000-01-02", "do": "2000-01-01"}`},
+		{"błędna data", `{"od": "2000-13-01"}`},
+		{"błędna płeć", `{"plec": "X"}`},
+	}
+	for _, tt := range testy {
+		var blad odpowiedzBledu
+		status := wyslij(t, "POST", "/pesel/generate", tt.tresc, &blad)
+		if status != http.StatusBadRequest || blad.Kod != kodBledneParametry {
+			t.Errorf("%s: status %d, kod %q; oczekiwano 400, %q", tt.nazwa, status, blad.Kod, kodBledneParametry)
+		}
+	}
+}
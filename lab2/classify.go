@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// stopWords to słowa pomijane w opisach pozycji: spójniki i przyimki we
+// wszystkich językach słownika oraz jednostki miary
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "for": true, "in": true, "of": true, "or": true, "the": true, "with": true,
+	"der": true, "die": true, "das": true, "und": true, "fur": true, "mit": true, "von": true,
+	"de": true, "del": true, "la": true, "las": true, "el": true, "los": true, "y": true, "para": true, "con": true,
+	"d": true, "des": true, "du": true, "le": true, "les": true, "et": true, "pour": true, "avec": true, "l": true,
+	"da": true, "do": true, "dos": true, "e": true, "com": true, "o": true, "os": true,
+	"kg": true, "g": true, "mg": true, "t": true, "ml": true, "m": true, "cm": true, "mm": true,
+	"pcs": true, "pc": true, "szt": true, "x": true,
+}
+
+// minClassifierCoverage to minimalny udział słów opisu, które muszą wystąpić
+// w etykiecie, żeby kod był kandydatem
+const minClassifierCoverage = 0.3
+
+// Candidate to proponowany kod CPV dla opisu pozycji
+type Candidate struct {
+	Node     *CPVNode
+	Score    float64
+	Language Language
+}
+
+// Classifier przypisuje opisom pozycji kody CPV, porównując słowa opisu
+// z etykietami słownika rankingiem BM25. Działa w całości na danych w pamięci.
+type Classifier struct {
+	index *SearchIndex
+}
+
+// NewClassifier tworzy klasyfikator korzystający z indeksu etykiet
+func NewClassifier(index *SearchIndex) *Classifier {
+	return &Classifier{index: index}
+}
+
+// descriptionTerms dzieli opis na słowa, pomijając liczby, ilości ("20kg") i słowa z stopWords
+func descriptionTerms(description string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, token := range tokenize(description) {
+		if stopWords[token] || strings.ContainsAny(token, "0123456789") || seen[token] {
+			continue
+		}
+		seen[token] = true
+		terms = append(terms, token)
+	}
+	return terms
+}
+
+// termVariants zwraca słowo i jego formę bez końcówki liczby mnogiej,
+// żeby "seed" pasowało do "seeds", a "tomatoes" do "tomato"
+func termVariants(term string) []string {
+	variants := []string{term}
+	if len(term) > 4 && strings.HasSuffix(term, "es") {
+		variants = append(variants, term[:len(term)-2])
+	}
+	if len(term) > 3 && strings.HasSuffix(term, "s") {
+		variants = append(variants, term[:len(term)-1])
+	}
+	return variants
+}
+
+// Classify zwraca do k kodów najlepiej pasujących do opisu. W przeciwieństwie
+// do Search kod nie musi zawierać wszystkich słów opisu: ocena BM25 jest
+// mnożona przez udział znalezionych słów, więc słowa bez znaczenia dla
+// klasyfikacji (np. "bags", "delivery") obniżają ocenę, ale nie wykluczają kodu.
+func (c *Classifier) Classify(description string, k int, languages ...Language) ([]Candidate, error) {
+	terms := descriptionTerms(description)
+	if len(terms) == 0 || k < 1 {
+		return nil, nil
+	}
+	if len(languages) == 0 {
+		languages = Languages
+	}
+
+	best := make(map[*CPVNode]SearchResult)
+	for _, lang := range languages {
+		li, ok := c.index.languages[lang]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownLanguage, lang)
+		}
+		scores := make(map[*CPVNode]float64)
+		matched := make(map[*CPVNode]int)
+		for _, term := range terms {
+			termBest := make(map[*CPVNode]float64)
+			for _, variant := range termVariants(term) {
+				for indexed, weight := range li.matchingTerms(variant, defaultMinPrefixLength) {
+					postings := li.postings[indexed]
+					for _, p := range postings {
+						if score := weight * li.bm25(len(postings), p); score > termBest[p.node] {
+							termBest[p.node] = score
+						}
+					}
+				}
+			}
+			for node, score := range termBest {
+				scores[node] += score
+				matched[node]++
+			}
+		}
+		for node, score := range scores {
+			coverage := float64(matched[node]) / float64(len(terms))
+			if coverage < minClassifierCoverage {
+				continue
+			}
+			if score *= coverage; score > best[node].Score {
+				best[node] = SearchResult{Node: node, Score: score, Language: lang}
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(best))
+	for _, result := range best {
+		results = append(results, result)
+	}
+	sortResults(results)
+
+	candidates := make([]Candidate, 0, min(k, len(results)))
+	for _, result := range results[:min(k, len(results))] {
+		candidates = append(candidates, Candidate(result))
+	}
+	return candidates, nil
+}
+
+// LabelledExample to opis pozycji z kodami uznanymi za poprawne
+type LabelledExample struct {
+	Description string
+	Codes       []string // short_code
+}
+
+// ReadLabelledExamples wczytuje zbiór ewaluacyjny: w każdej linii opis,
+// tabulator i poprawne kody oddzielone przecinkami. Puste linie i linie
+// zaczynające się od "#" są pomijane.
+func ReadLabelledExamples(r io.Reader) ([]LabelledExample, error) {
+	var examples []LabelledExample
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		description, codes, ok := strings.Cut(text, "\t")
+		if !ok {
+			return nil, &RowError{Line: line, Err: fmt.Errorf("%w: brak kolumny z kodami", ErrColumnCount)}
+		}
+		example := LabelledExample{Description: strings.TrimSpace(description)}
+		for _, code := range strings.Split(codes, ",") {
+			if code = shortCodeOf(code); code != "" {
+				example.Codes = append(example.Codes, code)
+			}
+		}
+		examples = append(examples, example)
+	}
+	return examples, scanner.Err()
+}
+
+// Evaluation to wynik klasyfikacji zbioru ewaluacyjnego
+type Evaluation struct {
+	K          int
+	Examples   int
+	PrecisionK float64 // średni udział poprawnych kodów wśród k propozycji
+	HitRateK   float64 // udział opisów, dla których poprawny kod jest wśród k propozycji
+	Misses     []LabelledExample
+}
+
+// Evaluate klasyfikuje wszystkie przykłady i liczy precision@k oraz hit rate@k
+func (c *Classifier) Evaluate(examples []LabelledExample, k int) (Evaluation, error) {
+	eval := Evaluation{K: k, Examples: len(examples)}
+	if len(examples) == 0 || k < 1 {
+		return eval, nil
+	}
+	for _, example := range examples {
+		candidates, err := c.Classify(example.Description, k)
+		if err != nil {
+			return eval, err
+		}
+		expected := make(map[string]bool, len(example.Codes))
+		for _, code := range example.Codes {
+			expected[code] = true
+		}
+		relevant := 0
+		for _, candidate := range candidates {
+			if expected[candidate.Node.ShortCode] {
+				relevant++
+			}
+		}
+		eval.PrecisionK += float64(relevant) / float64(k)
+		if relevant > 0 {
+			eval.HitRateK++
+		} else {
+			eval.Misses = append(eval.Misses, example)
+		}
+	}
+	eval.PrecisionK /= float64(len(examples))
+	eval.HitRateK /= float64(len(examples))
+	return eval, nil
+}
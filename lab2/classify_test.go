@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestClassifierQuality sprawdza jakość klasyfikatora na zbiorze cpv-eval.tsv.
+// Progi leżą tuż poniżej obecnych wyników, więc zmiana rankingu, która je
+// pogarsza, zatrzyma testy.
+func TestClassifierQuality(t *testing.T) {
+	_, tree, err := loadTree(defaultNomenclaturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open("cpv-eval.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	examples, err := ReadLabelledExamples(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) < 30 {
+		t.Fatalf("evaluation set has %d examples, want at least 30", len(examples))
+	}
+
+	classifier := NewClassifier(NewSearchIndex(tree))
+	tests := []struct {
+		k            int
+		minPrecision float64
+		minHitRate   float64
+	}{
+		{1, 0.82, 0.82},
+		{3, 0.30, 0.93},
+		{5, 0.19, 0.96},
+	}
+	for _, tt := range tests {
+		eval, err := classifier.Evaluate(examples, tt.k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("precision@%d %.3f, hit@%d %.3f", tt.k, eval.PrecisionK, tt.k, eval.HitRateK)
+		if eval.PrecisionK < tt.minPrecision || eval.HitRateK < tt.minHitRate {
+			for _, miss := range eval.Misses {
+				t.Logf("miss: %s (want %s)", miss.Description, strings.Join(miss.Codes, ", "))
+			}
+			t.Errorf("precision@%d %.3f, hit@%d %.3f; want at least %.2f and %.2f",
+				tt.k, eval.PrecisionK, tt.k, eval.HitRateK, tt.minPrecision, tt.minHitRate)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	classifier := NewClassifier(searchFixture(t))
+	examples := []LabelledExample{
+		{Description: "roasted peanuts, 500 g", Codes: []string{"03111200"}},
+		{Description: "crème fraîche", Codes: []string{"15500000", "15000000"}},
+		{Description: "office chairs", Codes: []string{"39112000"}},
+	}
+	eval, err := classifier.Evaluate(examples, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// trafienia: 1 z 2, 1 z 2 i 0 z 2 propozycji
+	if eval.Examples != 3 || math.Abs(eval.PrecisionK-1.0/3) > 1e-9 || math.Abs(eval.HitRateK-2.0/3) > 1e-9 {
+		t.Errorf("got %+v, want precision@2 0.333 and hit@2 0.667", eval)
+	}
+	if len(eval.Misses) != 1 || eval.Misses[0].Description != "office chairs" {
+		t.Errorf("misses %+v, want office chairs", eval.Misses)
+	}
+
+	if empty, err := classifier.Evaluate(nil, 5); err != nil || empty.PrecisionK != 0 || empty.HitRateK != 0 {
+		t.Errorf("empty set: %+v, %v", empty, err)
+	}
+}
+
+func TestReadLabelledExamples(t *testing.T) {
+	input := "# komentarz\n\nmustard seed\t03111600-8\n  fresh apples \t03222321, 03222320 ,\n"
+	examples, err := ReadLabelledExamples(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(examples) != 2 || examples[0].Description != "mustard seed" || strings.Join(examples[0].Codes, ",") != "03111600" ||
+		examples[1].Description != "fresh apples" || strings.Join(examples[1].Codes, ",") != "03222321,03222320" {
+		t.Errorf("got %+v", examples)
+	}
+
+	_, err = ReadLabelledExamples(strings.NewReader("# komentarz\nmustard seed 03111600\n"))
+	var rowErr *RowError
+	if !errors.As(err, &rowErr) || rowErr.Line != 2 {
+		t.Errorf("missing codes column: %v, want an error on line 2", err)
+	}
+}
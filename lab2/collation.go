@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Porównywanie etykiet odbywa się na trzech poziomach, podobnie jak w algorytmie
+// Unicode Collation: najpierw litery bazowe, potem znaki diakrytyczne, na końcu
+// wielkość liter. Dzięki temu "Éclair" trafia obok "eclair", a nie za "Z".
+
+// Znaki diakrytyczne rozróżniane na drugim poziomie porównania
+const (
+	markNone = iota
+	markAcute
+	markGrave
+	markCircumflex
+	markTilde
+	markDiaeresis
+	markRing
+	markCedilla
+	markOgonek
+	markStroke
+	markDot
+	markLigature
+)
+
+type decomposition struct {
+	base string
+	mark int
+}
+
+// decompositions rozkłada małe litery z diakrytykami na literę bazową i znak
+var decompositions = map[rune]decomposition{
+	'á': {"a", markAcute}, 'à': {"a", markGrave}, 'â': {"a", markCircumflex}, 'ã': {"a", markTilde},
+	'ä': {"a", markDiaeresis}, 'å': {"a", markRing}, 'ą': {"a", markOgonek}, 'æ': {"ae", markLigature},
+	'ç': {"c", markCedilla}, 'ć': {"c", markAcute},
+	'é': {"e", markAcute}, 'è': {"e", markGrave}, 'ê': {"e", markCircumflex}, 'ë': {"e", markDiaeresis}, 'ę': {"e", markOgonek},
+	'í': {"i", markAcute}, 'ì': {"i", markGrave}, 'î': {"i", markCircumflex}, 'ï': {"i", markDiaeresis},
+	'ł': {"l", markStroke}, 'ñ': {"n", markTilde}, 'ń': {"n", markAcute},
+	'ó': {"o", markAcute}, 'ò': {"o", markGrave}, 'ô': {"o", markCircumflex}, 'õ': {"o", markTilde},
+	'ö': {"o", markDiaeresis}, 'ø': {"o", markStroke}, 'œ': {"oe", markLigature},
+	'ś': {"s", markAcute}, 'ß': {"ss", markLigature},
+	'ú': {"u", markAcute}, 'ù': {"u", markGrave}, 'û': {"u", markCircumflex}, 'ü': {"u", markDiaeresis},
+	'ý': {"y", markAcute}, 'ÿ': {"y", markDiaeresis},
+	'ź': {"z", markAcute}, 'ż': {"z", markDot},
+}
+
+// Wagi pierwszego poziomu: odstępy < interpunkcja < cyfry < litery < pozostałe znaki
+const (
+	weightSpace       = 1
+	weightPunctuation = 10
+	weightDigit       = 1000
+	weightLetter      = 2000
+	weightOther       = 10000
+)
+
+// letterWeight zwraca wagę litery łacińskiej; litery leżą co 2, żeby zostawić
+// miejsce na litery dodatkowe w danym języku (np. hiszpańskie ñ po n)
+func letterWeight(r rune) int {
+	return weightLetter + int(r-'a')*2
+}
+
+// Collator porównuje tekst według reguł sortowania danego języka
+type Collator struct {
+	lang Language
+}
+
+// NewCollator tworzy porównywarkę dla języka etykiet. Różnice między językami:
+// w ES "ñ" jest osobną literą po "n"; w DE, FR, PT i EN litery z diakrytykami
+// sortują się razem z literą bazową, a "ß" jak "ss".
+func NewCollator(lang Language) *Collator {
+	return &Collator{lang: lang}
+}
+
+// CollationKey to klucz sortowania tekstu; porównywanie kluczy jest szybsze
+// niż wielokrotne porównywanie tekstów
+type CollationKey struct {
+	primary   []int
+	secondary []int
+	tertiary  []int
+}
+
+// Key wylicza klucz sortowania tekstu
+func (c *Collator) Key(s string) CollationKey {
+	var key CollationKey
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		caseWeight := 0
+		if lower != r {
+			caseWeight = 1
+		}
+
+		if c.lang == LangES && lower == 'ñ' {
+			key.primary = append(key.primary, letterWeight('n')+1)
+			key.secondary = append(key.secondary, markNone)
+			key.tertiary = append(key.tertiary, caseWeight)
+			continue
+		}
+
+		base, mark := string(lower), markNone
+		if d, ok := decompositions[lower]; ok {
+			base, mark = d.base, d.mark
+		}
+		for _, b := range base {
+			var weight int
+			switch {
+			case b >= 'a' && b <= 'z':
+				weight = letterWeight(b)
+			case b >= '0' && b <= '9':
+				weight = weightDigit + int(b-'0')
+			case unicode.IsSpace(b):
+				weight = weightSpace
+			case unicode.IsPunct(b) || unicode.IsSymbol(b):
+				// interpunkcja między sobą zachowuje kolejność kodów znaków
+				weight = weightPunctuation + min(int(b), weightDigit-weightPunctuation-1)
+			default:
+				weight = weightOther + int(b)
+			}
+			key.primary = append(key.primary, weight)
+			key.secondary = append(key.secondary, mark)
+			key.tertiary = append(key.tertiary, caseWeight)
+		}
+	}
+	return key
+}
+
+func compareInts(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// Compare porównuje dwa klucze: -1, 0 lub 1
+func (k CollationKey) Compare(other CollationKey) int {
+	if c := compareInts(k.primary, other.primary); c != 0 {
+		return c
+	}
+	if c := compareInts(k.secondary, other.secondary); c != 0 {
+		return c
+	}
+	return compareInts(k.tertiary, other.tertiary)
+}
+
+// Compare porównuje dwa teksty według reguł języka: -1, 0 lub 1
+func (c *Collator) Compare(a, b string) int {
+	return c.Key(a).Compare(c.Key(b))
+}
+
+// SortKey określa jedną kolumnę sortowania: "code", "short_code" albo język
+// etykiety ("de", "en", "es", "fr", "pt")
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// SortNomenclatures sortuje rekordy stabilnie według kolejnych kluczy, np.
+// najpierw według etykiety FR, potem według kodu. Etykiety są porównywane
+// regułami swojego języka.
+func SortNomenclatures(items []Nomenclature, keys ...SortKey) error {
+	// klucze sortowania etykiet liczymy raz dla każdego rekordu
+	labelKeys := make([][]CollationKey, len(keys))
+	for k, sortKey := range keys {
+		switch sortKey.Column {
+		case "code", "short_code":
+			continue
+		}
+		lang, err := ParseLanguage(sortKey.Column)
+		if err != nil {
+			return fmt.Errorf("nieznana kolumna sortowania %q: %w", sortKey.Column, err)
+		}
+		collator := NewCollator(lang)
+		labelKeys[k] = make([]CollationKey, len(items))
+		for i, item := range items {
+			labelKeys[k][i] = collator.Key(item.Label(lang))
+		}
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		for k, sortKey := range keys {
+			var c int
+			switch sortKey.Column {
+			case "code":
+				c = strings.Compare(items[a].Code, items[b].Code)
+			case "short_code":
+				c = strings.Compare(items[a].ShortCode, items[b].ShortCode)
+			default:
+				c = labelKeys[k][a].Compare(labelKeys[k][b])
+			}
+			if sortKey.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	sorted := make([]Nomenclature, len(items))
+	for i, index := range order {
+		sorted[i] = items[index]
+	}
+	copy(items, sorted)
+	return nil
+}
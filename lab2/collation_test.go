@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCollatorOrder(t *testing.T) {
+	tests := []struct {
+		lang  Language
+		order []string // oczekiwana kolejność po posortowaniu
+	}{
+		// litery z diakrytykami obok litery bazowej, a nie za "z"
+		{LangEN, []string{"Cukier", "Ćwikła", "Cz", "Zebra"}},
+		{LangFR, []string{"eclair", "Eclair", "éclair", "Éclair", "ecole", "zèbre"}},
+		// przy remisie liter decyduje akcent, dopiero potem wielkość liter
+		{LangFR, []string{"cote", "Cote", "coté", "côte", "côté"}},
+		{LangFR, []string{"a", "á", "à", "â", "ã", "ä", "å", "b"}},
+		// w ES "ñ" jest osobną literą między "n" i "o"
+		{LangES, []string{"nube", "nzz", "ñandú", "Ñu", "oso"}},
+		// w EN "ñ" sortuje się razem z "n"
+		{LangEN, []string{"nandu", "ñandu", "nube"}},
+		// "ß" jak "ss"
+		{LangDE, []string{"Strasse", "Straße", "Strassen", "Strasst"}},
+		{LangDE, []string{"Masse", "Maße", "Mast"}},
+		// odstępy < interpunkcja < cyfry < litery
+		{LangEN, []string{"a b", "a-b", "a1", "ab"}},
+		{LangEN, []string{"", "0", "9", "a"}},
+	}
+	for _, tt := range tests {
+		collator := NewCollator(tt.lang)
+		for i := 1; i < len(tt.order); i++ {
+			if c := collator.Compare(tt.order[i-1], tt.order[i]); c != -1 {
+				t.Errorf("%s: Compare(%q, %q) = %d, want -1", tt.lang, tt.order[i-1], tt.order[i], c)
+			}
+			if c := collator.Compare(tt.order[i], tt.order[i-1]); c != 1 {
+				t.Errorf("%s: Compare(%q, %q) = %d, want 1", tt.lang, tt.order[i], tt.order[i-1], c)
+			}
+		}
+
+		shuffled := slices.Clone(tt.order)
+		slices.Reverse(shuffled)
+		slices.SortFunc(shuffled, collator.Compare)
+		if !slices.Equal(shuffled, tt.order) {
+			t.Errorf("%s: sorted %q, want %q", tt.lang, shuffled, tt.order)
+		}
+	}
+
+	collator := NewCollator(LangFR)
+	for _, s := range []string{"Éclair", "Straße", ""} {
+		if c := collator.Compare(s, s); c != 0 {
+			t.Errorf("Compare(%q, %q) = %d, want 0", s, s, c)
+		}
+	}
+}
+
+func TestSortNomenclatures(t *testing.T) {
+	items := []Nomenclature{
+		{Code: "1", ShortCode: "1", FRLabel: "Œufs", ENLabel: "Eggs"},
+		{Code: "2", ShortCode: "2", FRLabel: "éclair", ENLabel: "Same"},
+		{Code: "3", ShortCode: "3", FRLabel: "Zèbre", ENLabel: "Same"},
+		{Code: "4", ShortCode: "4", FRLabel: "Eclair", ENLabel: "Same"},
+		{Code: "5", ShortCode: "5", FRLabel: "oeuf", ENLabel: "Eggs"},
+	}
+	codes := func() string {
+		var sb strings.Builder
+		for _, item := range items {
+			sb.WriteString(item.Code)
+		}
+		return sb.String()
+	}
+
+	tests := []struct {
+		keys []SortKey
+		want string
+	}{
+		{[]SortKey{{Column: "fr"}}, "42513"},
+		{[]SortKey{{Column: "fr", Descending: true}}, "31524"},
+		// równe etykiety zachowują poprzednią kolejność (sortowanie stabilne)
+		{[]SortKey{{Column: "en"}}, "15324"},
+		{[]SortKey{{Column: "en"}, {Column: "code", Descending: true}}, "51432"},
+		{[]SortKey{{Column: "short_code"}}, "12345"},
+		{[]SortKey{{Column: "en"}}, "15234"},
+	}
+	for _, tt := range tests {
+		if err := SortNomenclatures(items, tt.keys...); err != nil {
+			t.Fatal(err)
+		}
+		if got := codes(); got != tt.want {
+			t.Errorf("SortNomenclatures(%+v) = %s, want %s", tt.keys, got, tt.want)
+		}
+	}
+
+	if err := SortNomenclatures(items, SortKey{Column: "label"}); err == nil {
+		t.Error("SortNomenclatures with unknown column did not return an error")
+	}
+	if got := codes(); got != "15234" {
+		t.Errorf("failed SortNomenclatures changed the order to %s", got)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrCPVFormat         = errors.New("kod CPV musi mieć postać 12345678-9")
+	ErrCPVCheckDigit     = errors.New("niepoprawna cyfra kontrolna kodu CPV")
+	ErrShortCodeMismatch = errors.New("kod CPV nie zgadza się z kolumną short_code")
+	ErrUnknownCPVCode    = errors.New("kod CPV nie występuje w słowniku")
+)
+
+var cpvCheckDigitWeights = [8]int{3, 7, 1, 3, 7, 1, 3, 7}
+
+// CPVCode to kod słownika CPV rozłożony na części:
+// DD (dział), G (grupa), K (klasa), C (kategoria), SSS (podkategorie) i cyfra kontrolna
+type CPVCode struct {
+	Digits     [8]int
+	CheckDigit int
+}
+
+// ParseCPVCode odczytuje kod w postaci "03111200-4". Sprawdzany jest tylko format;
+// cyfrę kontrolną weryfikuje Verify albo CheckCPVCode.
+func ParseCPVCode(s string) (CPVCode, error) {
+	var code CPVCode
+	s = strings.TrimSpace(s)
+	if len(s) != 10 || s[8] != '-' {
+		return code, fmt.Errorf("%w: %q", ErrCPVFormat, s)
+	}
+	for i := 0; i < 8; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return code, fmt.Errorf("%w: %q", ErrCPVFormat, s)
+		}
+		code.Digits[i] = int(s[i] - '0')
+	}
+	if s[9] < '0' || s[9] > '9' {
+		return code, fmt.Errorf("%w: %q", ErrCPVFormat, s)
+	}
+	code.CheckDigit = int(s[9] - '0')
+	return code, nil
+}
+
+// Division zwraca dwie pierwsze cyfry kodu (dział, np. "03")
+func (c CPVCode) Division() string { return fmt.Sprintf("%d%d", c.Digits[0], c.Digits[1]) }
+
+// Group zwraca trzecią cyfrę kodu (grupa)
+func (c CPVCode) Group() int { return c.Digits[2] }
+
+// Class zwraca czwartą cyfrę kodu (klasa)
+func (c CPVCode) Class() int { return c.Digits[3] }
+
+// Category zwraca piątą cyfrę kodu (kategoria)
+func (c CPVCode) Category() int { return c.Digits[4] }
+
+// Subcategory zwraca cyfry 6-8 kodu (podkategorie)
+func (c CPVCode) Subcategory() string {
+	return fmt.Sprintf("%d%d%d", c.Digits[5], c.Digits[6], c.Digits[7])
+}
+
+// ShortCode zwraca osiem cyfr kodu bez cyfry kontrolnej, tak jak w kolumnie short_code
+func (c CPVCode) ShortCode() string {
+	var sb strings.Builder
+	for _, digit := range c.Digits {
+		sb.WriteByte(byte('0' + digit))
+	}
+	return sb.String()
+}
+
+func (c CPVCode) String() string {
+	return fmt.Sprintf("%s-%d", c.ShortCode(), c.CheckDigit)
+}
+
+// ComputeCPVCheckDigit liczy cyfrę kontrolną jako sumę cyfr z wagami 3,7,1,...
+// modulo 10. Część kodów przenumerowanych w wersji słownika z 2008 r. zachowała
+// dawne cyfry kontrolne i nie spełnia tej reguły (zob. CheckCPVCode).
+func ComputeCPVCheckDigit(digits [8]int) int {
+	sum := 0
+	for i, weight := range cpvCheckDigitWeights {
+		sum += digits[i] * weight
+	}
+	return sum % 10
+}
+
+// Verify sprawdza cyfrę kontrolną algorytmem ComputeCPVCheckDigit
+func (c CPVCode) Verify() error {
+	if ComputeCPVCheckDigit(c.Digits) != c.CheckDigit {
+		return fmt.Errorf("%w: %s (oczekiwano %d)", ErrCPVCheckDigit, c, ComputeCPVCheckDigit(c.Digits))
+	}
+	return nil
+}
+
+// ParseCode odczytuje kod rekordu i sprawdza jego zgodność z kolumną short_code
+func (n Nomenclature) ParseCode() (CPVCode, error) {
+	code, err := ParseCPVCode(n.Code)
+	if err != nil {
+		return code, err
+	}
+	if code.ShortCode() != strings.TrimSpace(n.ShortCode) {
+		return code, fmt.Errorf("%w: %s i %s", ErrShortCodeMismatch, n.Code, n.ShortCode)
+	}
+	return code, nil
+}
+
+// CheckCPVCode sprawdza kod wpisany przez użytkownika. Jeśli podano słownik
+// (klucz to short_code), kod musi w nim istnieć z identyczną cyfrą kontrolną,
+// co obejmuje też kody z historycznymi cyframi kontrolnymi. Bez słownika
+// stosowany jest tylko algorytm ComputeCPVCheckDigit.
+func CheckCPVCode(s string, known map[string]Nomenclature) (CPVCode, error) {
+	code, err := ParseCPVCode(s)
+	if err != nil {
+		return code, err
+	}
+	if known == nil {
+		return code, code.Verify()
+	}
+
+	item, ok := known[code.ShortCode()]
+	if !ok {
+		return code, fmt.Errorf("%w: %s", ErrUnknownCPVCode, code)
+	}
+	official, err := ParseCPVCode(item.Code)
+	if err != nil {
+		return code, err
+	}
+	if official.CheckDigit != code.CheckDigit {
+		return code, fmt.Errorf("%w: %s (oczekiwano %s)", ErrCPVCheckDigit, code, official)
+	}
+	return code, nil
+}
+
+// IndexByShortCode buduje mapę rekordów według kolumny short_code
+func IndexByShortCode(nomenclatures []Nomenclature) map[string]Nomenclature {
+	index := make(map[string]Nomenclature, len(nomenclatures))
+	for _, item := range nomenclatures {
+		index[item.ShortCode] = item
+	}
+	return index
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComputeCPVCheckDigit(t *testing.T) {
+	tests := []struct {
+		code  string
+		check int
+	}{
+		{"03000000", 1},
+		{"03111200", 4},
+		{"03111600", 8},
+		{"15000000", 8},
+		{"00000000", 0},
+		{"99999999", 8},
+		// kody z historyczną cyfrą kontrolną: algorytm daje inną cyfrę niż słownik
+		{"03322100", 5},
+		{"09132300", 0},
+	}
+	for _, tt := range tests {
+		code, err := ParseCPVCode(tt.code + "-0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ComputeCPVCheckDigit(code.Digits); got != tt.check {
+			t.Errorf("ComputeCPVCheckDigit(%s) = %d, want %d", tt.code, got, tt.check)
+		}
+	}
+}
+
+func TestParseCPVCode(t *testing.T) {
+	code, err := ParseCPVCode(" 03111200-4 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.String() != "03111200-4" || code.ShortCode() != "03111200" || code.Division() != "03" ||
+		code.Group() != 1 || code.Class() != 1 || code.Category() != 1 || code.Subcategory() != "200" {
+		t.Errorf("ParseCPVCode(03111200-4) = %+v", code)
+	}
+	if err := code.Verify(); err != nil {
+		t.Errorf("Verify(%s) = %v", code, err)
+	}
+	if err := (CPVCode{Digits: code.Digits, CheckDigit: 5}).Verify(); !errors.Is(err, ErrCPVCheckDigit) {
+		t.Errorf("Verify(03111200-5) = %v, want %v", err, ErrCPVCheckDigit)
+	}
+
+	for _, s := range []string{"", "03111200", "031112004", "03111200-", "03111200-45", "0311120-4",
+		"031112000-4", "03111200_4", "0311a200-4", "03111200-x", "０3111200-4"} {
+		if _, err := ParseCPVCode(s); !errors.Is(err, ErrCPVFormat) {
+			t.Errorf("ParseCPVCode(%q) = %v, want %v", s, err, ErrCPVFormat)
+		}
+	}
+}
+
+func TestNomenclatureParseCode(t *testing.T) {
+	if _, err := (Nomenclature{Code: "03111200-4", ShortCode: "03111200"}).ParseCode(); err != nil {
+		t.Errorf("matching short_code: %v", err)
+	}
+	if _, err := (Nomenclature{Code: "03111200-4", ShortCode: "03111300"}).ParseCode(); !errors.Is(err, ErrShortCodeMismatch) {
+		t.Errorf("mismatched short_code: %v, want %v", err, ErrShortCodeMismatch)
+	}
+	if _, err := (Nomenclature{Code: "03111200", ShortCode: "03111200"}).ParseCode(); !errors.Is(err, ErrCPVFormat) {
+		t.Errorf("code without check digit: %v, want %v", err, ErrCPVFormat)
+	}
+}
+
+// Słownik dołączony do repozytorium zawiera 532 kody z historyczną cyfrą
+// kontrolną; CheckCPVCode ze słownikiem musi je przyjmować
+func TestCheckCPVCodeLegacyCheckDigits(t *testing.T) {
+	nomenclatures, err := LoadNomenclature(defaultNomenclaturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	known := IndexByShortCode(nomenclatures)
+
+	legacy := 0
+	for _, item := range nomenclatures {
+		code, err := item.ParseCode()
+		if err != nil {
+			t.Fatalf("%s: %v", item.Code, err)
+		}
+		if _, err := CheckCPVCode(item.Code, known); err != nil {
+			t.Errorf("CheckCPVCode(%s) with dictionary: %v", item.Code, err)
+		}
+		if code.Verify() != nil {
+			legacy++
+			if _, err := CheckCPVCode(item.Code, nil); !errors.Is(err, ErrCPVCheckDigit) {
+				t.Errorf("CheckCPVCode(%s) without dictionary: %v, want %v", item.Code, err, ErrCPVCheckDigit)
+			}
+			// cyfra wyliczona algorytmem nie jest tą ze słownika
+			computed := CPVCode{Digits: code.Digits, CheckDigit: ComputeCPVCheckDigit(code.Digits)}
+			if _, err := CheckCPVCode(computed.String(), known); !errors.Is(err, ErrCPVCheckDigit) {
+				t.Errorf("CheckCPVCode(%s) with dictionary: %v, want %v", computed, err, ErrCPVCheckDigit)
+			}
+		}
+	}
+	if legacy != 532 {
+		t.Errorf("found %d codes with legacy check digits, want 532", legacy)
+	}
+
+	tests := []struct {
+		code string
+		err  error
+	}{
+		{"03111200-4", nil},
+		{"03322100-3", nil},
+		{"03111200-5", ErrCPVCheckDigit},
+		{"99999999-2", ErrUnknownCPVCode},
+		{"03111200", ErrCPVFormat},
+	}
+	for _, tt := range tests {
+		if _, err := CheckCPVCode(tt.code, known); !errors.Is(err, tt.err) {
+			t.Errorf("CheckCPVCode(%s) = %v, want %v", tt.code, err, tt.err)
+		}
+	}
+}
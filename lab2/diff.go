@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"lab6/delimited"
+)
+
+// LabelChange opisuje zmianę etykiety kodu w jednym języku
+type LabelChange struct {
+	Language Language
+	Old      string
+	New      string
+}
+
+// CodeChange opisuje kod obecny w obu wersjach słownika, który zmienił
+// cyfrę kontrolną lub etykiety
+type CodeChange struct {
+	ShortCode string
+	OldCode   string
+	NewCode   string
+	Labels    []LabelChange
+}
+
+// NomenclatureDiff to różnice między dwiema wersjami słownika CPV
+type NomenclatureDiff struct {
+	Added   []Nomenclature
+	Removed []Nomenclature
+	Changed []CodeChange
+}
+
+// DiffNomenclatures porównuje dwie wersje słownika według kolumny short_code.
+// Wszystkie listy są posortowane według kodu.
+func DiffNomenclatures(oldItems, newItems []Nomenclature) NomenclatureDiff {
+	var diff NomenclatureDiff
+	oldIndex := IndexByShortCode(oldItems)
+	newIndex := IndexByShortCode(newItems)
+
+	for shortCode, newItem := range newIndex {
+		oldItem, ok := oldIndex[shortCode]
+		if !ok {
+			diff.Added = append(diff.Added, newItem)
+			continue
+		}
+		change := CodeChange{ShortCode: shortCode, OldCode: oldItem.Code, NewCode: newItem.Code}
+		for _, lang := range Languages {
+			if oldItem.Label(lang) != newItem.Label(lang) {
+				change.Labels = append(change.Labels, LabelChange{Language: lang, Old: oldItem.Label(lang), New: newItem.Label(lang)})
+			}
+		}
+		if change.OldCode != change.NewCode || len(change.Labels) > 0 {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for shortCode, oldItem := range oldIndex {
+		if _, ok := newIndex[shortCode]; !ok {
+			diff.Removed = append(diff.Removed, oldItem)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].ShortCode < diff.Added[j].ShortCode })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].ShortCode < diff.Removed[j].ShortCode })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ShortCode < diff.Changed[j].ShortCode })
+	return diff
+}
+
+// WriteReport zapisuje różnice jako wiersze oddzielone tabulatorami:
+// "+" kod dodany, "-" kod usunięty, "=" zmieniona cyfra kontrolna,
+// "~" zmieniona etykieta w danym języku (stara i nowa wartość)
+func (d NomenclatureDiff) WriteReport(w io.Writer) error {
+	for _, item := range d.Added {
+		if _, err := fmt.Fprintf(w, "+\t%s\t%s\n", item.Code, tsvField(item.ENLabel)); err != nil {
+			return err
+		}
+	}
+	for _, item := range d.Removed {
+		if _, err := fmt.Fprintf(w, "-\t%s\t%s\n", item.Code, tsvField(item.ENLabel)); err != nil {
+			return err
+		}
+	}
+	for _, change := range d.Changed {
+		if change.OldCode != change.NewCode {
+			if _, err := fmt.Fprintf(w, "=\t%s\t%s\t%s\n", change.ShortCode, change.OldCode, change.NewCode); err != nil {
+				return err
+			}
+		}
+		for _, label := range change.Labels {
+			if _, err := fmt.Fprintf(w, "~\t%s\t%s\t%s\t%s\n", change.NewCode, label.Language, tsvField(label.Old), tsvField(label.New)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Correspondence to tabela przejścia: stary short_code -> nowy short_code
+type Correspondence map[string]string
+
+// shortCodeOf zwraca osiem cyfr kodu podanego jako "03111200-4" albo "03111200"
+func shortCodeOf(code string) string {
+	code = strings.TrimSpace(code)
+	if len(code) == 10 && code[8] == '-' {
+		return code[:8]
+	}
+	return code
+}
+
+// ReadCorrespondence wczytuje tabelę przejścia z dwiema kolumnami (stary kod,
+// nowy kod). Separator 0 oznacza wykrycie ';', ',' albo tabulatora z pierwszej
+// linii. Pierwszy wiersz bez cyfr na początku jest traktowany jako nagłówek.
+// Błędy wskazują numer linii w pliku.
+func ReadCorrespondence(r io.Reader, separator rune) (Correspondence, error) {
+	if separator == 0 {
+		buffered := bufio.NewReader(r)
+		separator = delimited.DetectComma(buffered)
+		r = buffered
+	}
+	reader := csv.NewReader(r)
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+
+	correspondence := make(Correspondence)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("błąd odczytu tabeli przejścia: %w", err)
+		}
+		// rekord w cudzysłowie może zajmować kilka linii, więc numer linii
+		// bierzemy z czytnika, a nie z licznika rekordów
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, &RowError{Line: line, Err: fmt.Errorf("%w: %d zamiast 2", ErrColumnCount, len(record))}
+		}
+		oldCode := shortCodeOf(strings.TrimPrefix(record[0], string(utf8BOM)))
+		if first && (oldCode == "" || oldCode[0] < '0' || oldCode[0] > '9') {
+			continue
+		}
+		correspondence[oldCode] = shortCodeOf(record[1])
+	}
+	return correspondence, nil
+}
+
+// UnmappedCode to kod z migrowanego pliku, dla którego nie ma odpowiednika
+type UnmappedCode struct {
+	Line int // numer linii w pliku (od 1)
+	Code string
+}
+
+// MigrateOptions opisuje plik z danymi, w którym podmieniane są kody
+type MigrateOptions struct {
+	Column    int  // numer kolumny z kodem CPV (od 1)
+	Separator rune // domyślnie ','
+	Header    bool // pierwszy wiersz jest przepisywany bez zmian
+}
+
+// MigrateCodes przepisuje plik CSV, zamieniając stare kody w kolumnie na nowe.
+// Kod jest zamieniany według tabeli przejścia, a jeśli jej nie zawiera, zostaje
+// bez zmian, o ile istnieje w nowym słowniku. Tabela może łączyć kilka wersji
+// słownika (A -> B, B -> C): przejścia są stosowane dalej, dopóki kod nie
+// istnieje w nowym słowniku. Pozostałe kody są przepisywane
+// bez zmian i zwracane jako nieprzypisane. Pełne kody ("03111200-4")
+// otrzymują cyfrę kontrolną z nowego słownika.
+func MigrateCodes(r io.Reader, w io.Writer, correspondence Correspondence, newIndex map[string]Nomenclature, opts MigrateOptions) ([]UnmappedCode, error) {
+	if opts.Column < 1 {
+		return nil, fmt.Errorf("niepoprawny numer kolumny: %d", opts.Column)
+	}
+	separator := opts.Separator
+	if separator == 0 {
+		separator = ','
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	writer := csv.NewWriter(w)
+	writer.Comma = separator
+
+	var unmapped []UnmappedCode
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return unmapped, fmt.Errorf("błąd odczytu danych: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if (!first || !opts.Header) && opts.Column <= len(record) && strings.TrimSpace(record[opts.Column-1]) != "" {
+			value := strings.TrimSpace(record[opts.Column-1])
+			shortCode := shortCodeOf(value)
+			if mapped, ok := correspondence[shortCode]; ok {
+				shortCode = mapped
+				// limit przejść chroni przed cyklem w tabeli
+				for hops := 0; hops < len(correspondence); hops++ {
+					if _, ok := newIndex[shortCode]; ok {
+						break
+					}
+					next, ok := correspondence[shortCode]
+					if !ok {
+						break
+					}
+					shortCode = next
+				}
+			}
+			if item, ok := newIndex[shortCode]; ok {
+				if len(value) == 10 {
+					record[opts.Column-1] = item.Code
+				} else {
+					record[opts.Column-1] = item.ShortCode
+				}
+			} else {
+				unmapped = append(unmapped, UnmappedCode{Line: line, Code: value})
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return unmapped, fmt.Errorf("błąd zapisu wiersza %d: %w", line, err)
+		}
+	}
+
+	writer.Flush()
+	return unmapped, writer.Error()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadCorrespondenceSeparator(t *testing.T) {
+	want := Correspondence{"03111200": "03111300", "15000000": "15100000"}
+	tests := []struct {
+		name      string
+		input     string
+		separator rune
+	}{
+		{"semicolon detected", "old;new\n03111200-4;03111300-5\n15000000;15100000\n", 0},
+		{"comma detected", "old,new\n03111200-4,03111300-5\n15000000,15100000\n", 0},
+		{"tab detected", "03111200-4\t03111300-5\n15000000\t15100000\n", 0},
+		{"comma inside quotes", "\"old, code\";new\n03111200-4;03111300-5\n15000000;15100000\n", 0},
+		{"BOM and CRLF", "\ufeffold,new\r\n03111200-4,03111300-5\r\n15000000,15100000\r\n", 0},
+		{"explicit separator", "old|new\n03111200-4|03111300-5\n15000000|15100000\n", '|'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCorrespondence(strings.NewReader(tt.input), tt.separator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffNomenclatures(t *testing.T) {
+	oldItems := []Nomenclature{
+		{Code: "03111200-4", ShortCode: "03111200", ENLabel: "Peanuts", FRLabel: "Graines d'arachide"},
+		{Code: "03111300-5", ShortCode: "03111300", ENLabel: "Sunflower seeds"},
+		{Code: "03111600-7", ShortCode: "03111600", ENLabel: "Mustard seeds", DELabel: "Senf"},
+		{Code: "15000000-8", ShortCode: "15000000", ENLabel: "Food"},
+	}
+	newItems := []Nomenclature{
+		{Code: "15100000-9", ShortCode: "15100000", ENLabel: "Animal\tproducts"},
+		{Code: "15000000-8", ShortCode: "15000000", ENLabel: "Food"},
+		{Code: "03111600-8", ShortCode: "03111600", ENLabel: "Mustard seeds", DELabel: "Senfsamen"},
+		{Code: "03111200-4", ShortCode: "03111200", ENLabel: "Peanuts", FRLabel: "Arachides"},
+	}
+	diff := DiffNomenclatures(oldItems, newItems)
+
+	var report strings.Builder
+	if err := diff.WriteReport(&report); err != nil {
+		t.Fatal(err)
+	}
+	want := "+\t15100000-9\tAnimal products\n" +
+		"-\t03111300-5\tSunflower seeds\n" +
+		"~\t03111200-4\tfr\tGraines d'arachide\tArachides\n" +
+		"=\t03111600\t03111600-7\t03111600-8\n" +
+		"~\t03111600-8\tde\tSenf\tSenfsamen\n"
+	if report.String() != want {
+		t.Errorf("report:\n%s\nwant:\n%s", report.String(), want)
+	}
+	if len(diff.Added) != 1 || len(diff.Removed) != 1 || len(diff.Changed) != 2 {
+		t.Errorf("got %d added, %d removed, %d changed; want 1, 1, 2", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+
+	if same := DiffNomenclatures(newItems, newItems); len(same.Added)+len(same.Removed)+len(same.Changed) != 0 {
+		t.Errorf("diff of identical versions: %+v", same)
+	}
+}
+
+// Numer linii błędu uwzględnia rekordy zajmujące kilka linii
+func TestReadCorrespondenceErrorLine(t *testing.T) {
+	input := "old;new\n\"03111200\n\";03111300\n15000000\n"
+	_, err := ReadCorrespondence(strings.NewReader(input), 0)
+	var rowErr *RowError
+	if !errors.As(err, &rowErr) || rowErr.Line != 4 || !errors.Is(err, ErrColumnCount) {
+		t.Errorf("got %v, want a column count error on line 4", err)
+	}
+}
+
+func TestMigrateCodes(t *testing.T) {
+	newIndex := IndexByShortCode([]Nomenclature{
+		{Code: "03111200-4", ShortCode: "03111200"},
+		{Code: "03111600-8", ShortCode: "03111600"},
+		{Code: "03111900-1", ShortCode: "03111900"},
+		{Code: "15000000-8", ShortCode: "15000000"},
+		{Code: "15100000-9", ShortCode: "15100000"},
+	})
+	correspondence := Correspondence{
+		// łańcuch przez kod, którego nie ma w nowym słowniku
+		"03111300": "03111350",
+		"03111350": "15100000",
+		// pierwsze przejście prowadzi do istniejącego kodu, dalej nie idziemy
+		"03111200": "03111600",
+		"03111600": "15000000",
+		// kod nadal istnieje, ale tabela przenosi go gdzie indziej
+		"15000000": "15100000",
+		// cykl bez kodu z nowego słownika
+		"09000000": "09100000",
+		"09100000": "09000000",
+	}
+	input := "id,cpv,note\n" +
+		"1,03111300-5,chain\n" +
+		"2,03111200,first hop\n" +
+		"3,15000000-8,moved\n" +
+		"4,\"09000000\",\"multi\nline note\"\n" +
+		"5,03111900-0,old check digit\n" +
+		"6,\n" +
+		"7\n" +
+		"8,99999999-2,unknown\n"
+	want := "id,cpv,note\n" +
+		"1,15100000-9,chain\n" +
+		"2,03111600,first hop\n" +
+		"3,15100000-9,moved\n" +
+		"4,09000000,\"multi\nline note\"\n" +
+		"5,03111900-1,old check digit\n" +
+		"6,\n" +
+		"7\n" +
+		"8,99999999-2,unknown\n"
+
+	var out strings.Builder
+	unmapped, err := MigrateCodes(strings.NewReader(input), &out, correspondence, newIndex, MigrateOptions{Column: 2, Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+	wantUnmapped := []UnmappedCode{{Line: 5, Code: "09000000"}, {Line: 10, Code: "99999999-2"}}
+	if !slices.Equal(unmapped, wantUnmapped) {
+		t.Errorf("unmapped %+v, want %+v", unmapped, wantUnmapped)
+	}
+
+	// bez nagłówka pierwszy wiersz też jest migrowany
+	out.Reset()
+	unmapped, err = MigrateCodes(strings.NewReader("03111300;x\n"), &out, correspondence, newIndex, MigrateOptions{Column: 1, Separator: ';'})
+	if err != nil || out.String() != "15100000;x\n" || len(unmapped) != 0 {
+		t.Errorf("without header: %q, %+v, %v", out.String(), unmapped, err)
+	}
+
+	if _, err := MigrateCodes(strings.NewReader(input), &out, correspondence, newIndex, MigrateOptions{}); err == nil {
+		t.Error("MigrateCodes with column 0 did not return an error")
+	}
+}
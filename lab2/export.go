@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ExportFormat to format eksportu słownika CPV
+type ExportFormat string
+
+const (
+	ExportJSON  ExportFormat = "json"  // zagnieżdżony JSON zgodny z hierarchią
+	ExportJSONL ExportFormat = "jsonl" // płaski JSON Lines, jeden kod w linii
+	ExportSQL   ExportFormat = "sql"   // skrypt SQL z tabelą cpv (zgodny z SQLite)
+	ExportTSV   ExportFormat = "tsv"   // wartości oddzielone tabulatorami
+)
+
+var ErrUnknownExportFormat = errors.New("nieznany format eksportu")
+
+// ExportOptions wybiera języki etykiet i opcjonalne poddrzewo do eksportu
+type ExportOptions struct {
+	Languages []Language // puste - wszystkie języki
+	Subtree   string     // kod, którego poddrzewo eksportować; puste - cały słownik
+}
+
+type exportedNode struct {
+	Code      string              `json:"code"`
+	ShortCode string              `json:"short_code"`
+	Level     int                 `json:"level"`
+	Parent    string              `json:"parent,omitempty"`
+	Labels    map[Language]string `json:"labels"`
+	Children  []*exportedNode     `json:"children,omitempty"`
+}
+
+// Export zapisuje drzewo CPV do w w wybranym formacie
+func Export(w io.Writer, tree *CPVTree, format ExportFormat, opts ExportOptions) error {
+	requested := opts.Languages
+	if len(requested) == 0 {
+		requested = Languages
+	}
+	// powtórzony język dałby np. dwie kolumny label_en w tabeli SQL
+	var languages []Language
+	for _, lang := range requested {
+		parsed, err := ParseLanguage(string(lang))
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(languages, parsed) {
+			languages = append(languages, parsed)
+		}
+	}
+
+	roots := tree.Roots
+	if opts.Subtree != "" {
+		node, err := tree.Node(opts.Subtree)
+		if err != nil {
+			return err
+		}
+		roots = []*CPVNode{node}
+	}
+
+	bw := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case ExportJSON:
+		err = exportJSON(bw, roots, languages)
+	case ExportJSONL:
+		err = exportJSONL(bw, roots, languages)
+	case ExportSQL:
+		err = exportSQL(bw, roots, languages)
+	case ExportTSV:
+		err = exportTSV(bw, roots, languages)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownExportFormat, format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// walkPreorder odwiedza węzły tak, że rodzic zawsze występuje przed dziećmi
+func walkPreorder(roots []*CPVNode, visit func(*CPVNode) error) error {
+	for _, node := range roots {
+		if err := visit(node); err != nil {
+			return err
+		}
+		if err := walkPreorder(node.Children, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExportedNode(node *CPVNode, languages []Language) *exportedNode {
+	exported := &exportedNode{
+		Code:      node.Code,
+		ShortCode: node.ShortCode,
+		Level:     node.Level,
+		Labels:    make(map[Language]string, len(languages)),
+	}
+	if node.Parent != nil {
+		exported.Parent = node.Parent.ShortCode
+	}
+	for _, lang := range languages {
+		exported.Labels[lang] = node.Label(lang)
+	}
+	return exported
+}
+
+func exportJSON(w io.Writer, roots []*CPVNode, languages []Language) error {
+	var build func(*CPVNode) *exportedNode
+	build = func(node *CPVNode) *exportedNode {
+		exported := newExportedNode(node, languages)
+		for _, child := range node.Children {
+			exported.Children = append(exported.Children, build(child))
+		}
+		return exported
+	}
+
+	tree := make([]*exportedNode, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tree)
+}
+
+func exportJSONL(w io.Writer, roots []*CPVNode, languages []Language) error {
+	encoder := json.NewEncoder(w)
+	return walkPreorder(roots, func(node *CPVNode) error {
+		return encoder.Encode(newExportedNode(node, languages))
+	})
+}
+
+// sqlString zapisuje tekst jako literał SQL, podwajając apostrofy
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func exportSQL(w io.Writer, roots []*CPVNode, languages []Language) error {
+	columns := []string{"short_code", "code", "parent_code", "level"}
+	var schema strings.Builder
+	schema.WriteString("CREATE TABLE IF NOT EXISTS cpv (\n")
+	schema.WriteString("    short_code TEXT PRIMARY KEY,\n")
+	schema.WriteString("    code TEXT NOT NULL UNIQUE,\n")
+	schema.WriteString("    parent_code TEXT REFERENCES cpv(short_code),\n")
+	schema.WriteString("    level INTEGER NOT NULL")
+	for _, lang := range languages {
+		fmt.Fprintf(&schema, ",\n    label_%s TEXT", lang)
+		columns = append(columns, "label_"+string(lang))
+	}
+	schema.WriteString("\n);\n")
+
+	if _, err := fmt.Fprintf(w, "%sCREATE INDEX IF NOT EXISTS cpv_parent ON cpv(parent_code);\n\nBEGIN TRANSACTION;\n", schema.String()); err != nil {
+		return err
+	}
+	// korzenie eksportu nie wskazują rodzica, który nie trafi do tabeli
+	exportRoots := make(map[*CPVNode]bool, len(roots))
+	for _, root := range roots {
+		exportRoots[root] = true
+	}
+	insert := "INSERT INTO cpv (" + strings.Join(columns, ", ") + ") VALUES ("
+	err := walkPreorder(roots, func(node *CPVNode) error {
+		parent := "NULL"
+		if node.Parent != nil && !exportRoots[node] {
+			parent = sqlString(node.Parent.ShortCode)
+		}
+		values := []string{sqlString(node.ShortCode), sqlString(node.Code), parent, fmt.Sprint(node.Level)}
+		for _, lang := range languages {
+			values = append(values, sqlString(node.Label(lang)))
+		}
+		_, err := fmt.Fprintf(w, "%s%s);\n", insert, strings.Join(values, ", "))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "COMMIT;\n")
+	return err
+}
+
+// tsvField usuwa z wartości tabulatory i końce linii, których TSV nie dopuszcza
+func tsvField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+func exportTSV(w io.Writer, roots []*CPVNode, languages []Language) error {
+	header := []string{"code", "short_code", "parent", "level"}
+	for _, lang := range languages {
+		header = append(header, "label_"+string(lang))
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(header, "\t")); err != nil {
+		return err
+	}
+	return walkPreorder(roots, func(node *CPVNode) error {
+		parent := ""
+		if node.Parent != nil {
+			parent = node.Parent.ShortCode
+		}
+		fields := []string{node.Code, node.ShortCode, parent, fmt.Sprint(node.Level)}
+		for _, lang := range languages {
+			fields = append(fields, tsvField(node.Label(lang)))
+		}
+		_, err := fmt.Fprintln(w, strings.Join(fields, "\t"))
+		return err
+	})
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// exportFixture to małe drzewo z etykietami zawierającymi cudzysłowy,
+// apostrofy i tabulator
+func exportFixture(t *testing.T) *CPVTree {
+	t.Helper()
+	labelled := func(shortCode, en, fr string) Nomenclature {
+		item := fixtureNomenclature(shortCode, en)
+		item.FRLabel = fr
+		return item
+	}
+	tree, err := BuildCPVTree([]Nomenclature{
+		labelled("15000000", "Food", "Aliments"),
+		labelled("03111200", "Peanuts", "Graines d'arachide\tgrillées"),
+		labelled("03000000", `Agricultural "raw" products`, "Produits d'agriculture"),
+		labelled("03111000", "Seeds", "Graines"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestExportGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		format ExportFormat
+		opts   ExportOptions
+		want   string
+	}{
+		{"nested JSON", ExportJSON, ExportOptions{Languages: []Language{LangEN, LangFR}}, `[
+  {
+    "code": "03000000-1",
+    "short_code": "03000000",
+    "level": 1,
+    "labels": {
+      "en": "Agricultural \"raw\" products",
+      "fr": "Produits d'agriculture"
+    },
+    "children": [
+      {
+        "code": "03111000-2",
+        "short_code": "03111000",
+        "level": 4,
+        "parent": "03000000",
+        "labels": {
+          "en": "Seeds",
+          "fr": "Graines"
+        },
+        "children": [
+          {
+            "code": "03111200-4",
+            "short_code": "03111200",
+            "level": 5,
+            "parent": "03111000",
+            "labels": {
+              "en": "Peanuts",
+              "fr": "Graines d'arachide\tgrillées"
+            }
+          }
+        ]
+      }
+    ]
+  },
+  {
+    "code": "15000000-8",
+    "short_code": "15000000",
+    "level": 1,
+    "labels": {
+      "en": "Food",
+      "fr": "Aliments"
+    }
+  }
+]
+`},
+		{"JSON Lines", ExportJSONL, ExportOptions{Languages: []Language{LangFR}}, `{"code":"03000000-1","short_code":"03000000","level":1,"labels":{"fr":"Produits d'agriculture"}}
+{"code":"03111000-2","short_code":"03111000","level":4,"parent":"03000000","labels":{"fr":"Graines"}}
+{"code":"03111200-4","short_code":"03111200","level":5,"parent":"03111000","labels":{"fr":"Graines d'arachide\tgrillées"}}
+{"code":"15000000-8","short_code":"15000000","level":1,"labels":{"fr":"Aliments"}}
+`},
+		{"JSON Lines subtree", ExportJSONL, ExportOptions{Languages: []Language{LangEN}, Subtree: "03111000-2"}, `{"code":"03111000-2","short_code":"03111000","level":4,"parent":"03000000","labels":{"en":"Seeds"}}
+{"code":"03111200-4","short_code":"03111200","level":5,"parent":"03111000","labels":{"en":"Peanuts"}}
+`},
+		{"TSV", ExportTSV, ExportOptions{Languages: []Language{LangEN, LangFR}}, "" +
+			"code\tshort_code\tparent\tlevel\tlabel_en\tlabel_fr\n" +
+			"03000000-1\t03000000\t\t1\tAgricultural \"raw\" products\tProduits d'agriculture\n" +
+			"03111000-2\t03111000\t03000000\t4\tSeeds\tGraines\n" +
+			"03111200-4\t03111200\t03111000\t5\tPeanuts\tGraines d'arachide grillées\n" +
+			"15000000-8\t15000000\t\t1\tFood\tAliments\n"},
+		// powtórzony język daje jedną kolumnę
+		{"SQL", ExportSQL, ExportOptions{Languages: []Language{LangEN, LangFR, "EN"}}, `CREATE TABLE IF NOT EXISTS cpv (
+    short_code TEXT PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    parent_code TEXT REFERENCES cpv(short_code),
+    level INTEGER NOT NULL,
+    label_en TEXT,
+    label_fr TEXT
+);
+CREATE INDEX IF NOT EXISTS cpv_parent ON cpv(parent_code);
+
+BEGIN TRANSACTION;
+INSERT INTO cpv (short_code, code, parent_code, level, label_en, label_fr) VALUES ('03000000', '03000000-1', NULL, 1, 'Agricultural "raw" products', 'Produits d''agriculture');
+INSERT INTO cpv (short_code, code, parent_code, level, label_en, label_fr) VALUES ('03111000', '03111000-2', '03000000', 4, 'Seeds', 'Graines');
+INSERT INTO cpv (short_code, code, parent_code, level, label_en, label_fr) VALUES ('03111200', '03111200-4', '03111000', 5, 'Peanuts', 'Graines d''arachide	grillées');
+INSERT INTO cpv (short_code, code, parent_code, level, label_en, label_fr) VALUES ('15000000', '15000000-8', NULL, 1, 'Food', 'Aliments');
+COMMIT;
+`},
+		// korzeń poddrzewa nie wskazuje rodzica spoza eksportu
+		{"SQL subtree", ExportSQL, ExportOptions{Languages: []Language{LangFR, LangFR}, Subtree: "03111000"}, `CREATE TABLE IF NOT EXISTS cpv (
+    short_code TEXT PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    parent_code TEXT REFERENCES cpv(short_code),
+    level INTEGER NOT NULL,
+    label_fr TEXT
+);
+CREATE INDEX IF NOT EXISTS cpv_parent ON cpv(parent_code);
+
+BEGIN TRANSACTION;
+INSERT INTO cpv (short_code, code, parent_code, level, label_fr) VALUES ('03111000', '03111000-2', NULL, 4, 'Graines');
+INSERT INTO cpv (short_code, code, parent_code, level, label_fr) VALUES ('03111200', '03111200-4', '03111000', 5, 'Graines d''arachide	grillées');
+COMMIT;
+`},
+	}
+	tree := exportFixture(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Export(&out, tree, tt.format, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	tree := exportFixture(t)
+	tests := []struct {
+		format ExportFormat
+		opts   ExportOptions
+		err    error
+	}{
+		{"xml", ExportOptions{}, ErrUnknownExportFormat},
+		{ExportSQL, ExportOptions{Languages: []Language{"pl"}}, ErrUnknownLanguage},
+		{ExportJSON, ExportOptions{Subtree: "99000000"}, ErrUnknownCPVCode},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := Export(&out, tree, tt.format, tt.opts); !errors.Is(err, tt.err) {
+			t.Errorf("Export(%s, %+v) = %v, want %v", tt.format, tt.opts, err, tt.err)
+		}
+		if out.Len() > 0 {
+			t.Errorf("Export(%s, %+v) wrote %q before failing", tt.format, tt.opts, out.String())
+		}
+	}
+}
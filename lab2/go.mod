@@ -0,0 +1,7 @@
+module lab2
+
+go 1.24.0
+
+require lab6 v0.0.0
+
+replace lab6 => ../lab6
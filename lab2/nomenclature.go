@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"lab6/delimited"
+)
+
+// Struktura opisująca jeden kod słownika CPV wraz z etykietami w pięciu językach;
+// tagi wskazują kolumny nagłówka pliku nomenclature-cpv.csv
+type Nomenclature struct {
+	Code      string `csv:"CODE CPV"`
+	DELabel   string `csv:"DE Label"`
+	ENLabel   string `csv:"EN Label"`
+	ESLabel   string `csv:"ES Label"`
+	FRLabel   string `csv:"FR Label"`
+	PTLabel   string `csv:"PT Label"`
+	ShortCode string `csv:"Short Code"`
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// RowError opisuje wiersz pliku, którego nie udało się odczytać
+type RowError = delimited.RowError
+
+// MalformedRowsError zbiera wszystkie błędne wiersze znalezione przez LoadNomenclature
+type MalformedRowsError = delimited.RowErrors
+
+var ErrColumnCount = delimited.ErrColumnCount
+
+// NomenclatureReader czyta plik CPV rekord po rekordzie, bez wczytywania
+// całego pliku do pamięci
+type NomenclatureReader struct {
+	reader *delimited.Reader[Nomenclature]
+}
+
+// NewNomenclatureReader pomija znacznik BOM, wczytuje nagłówek i dopasowuje
+// jego kolumny do pól Nomenclature; separator jest wykrywany z nagłówka
+func NewNomenclatureReader(r io.Reader) (*NomenclatureReader, error) {
+	reader, err := delimited.NewReader[Nomenclature](r, delimited.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return &NomenclatureReader{reader: reader}, nil
+}
+
+// Read zwraca kolejny rekord, io.EOF na końcu pliku albo *RowError dla
+// błędnego wiersza (po którym można czytać dalej)
+func (nr *NomenclatureReader) Read() (Nomenclature, error) {
+	return nr.reader.Read()
+}
+
+// ReadNomenclature wczytuje wszystkie rekordy z r. Poprawne rekordy są
+// zwracane zawsze; błędne wiersze są zgłaszane razem jako *MalformedRowsError.
+func ReadNomenclature(r io.Reader) ([]Nomenclature, error) {
+	return delimited.ReadAll[Nomenclature](r, delimited.Options{})
+}
+
+// LoadNomenclature otwiera plik CPV i wczytuje go przez ReadNomenclature
+func LoadNomenclature(filepath string) ([]Nomenclature, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("nie udało się otworzyć pliku %s: %w", filepath, err)
+	}
+	defer file.Close()
+
+	return ReadNomenclature(file)
+}
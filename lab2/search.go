@@ -0,0 +1,305 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Language to kod języka etykiety w słowniku CPV
+type Language string
+
+const (
+	LangDE Language = "de"
+	LangEN Language = "en"
+	LangES Language = "es"
+	LangFR Language = "fr"
+	LangPT Language = "pt"
+)
+
+// Languages to wszystkie języki etykiet w kolejności kolumn pliku CSV
+var Languages = []Language{LangDE, LangEN, LangES, LangFR, LangPT}
+
+var ErrUnknownLanguage = errors.New("nieznany język")
+
+// ParseLanguage zamienia tekst (np. "EN", "fr") na Language
+func ParseLanguage(s string) (Language, error) {
+	lang := Language(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Languages {
+		if lang == known {
+			return lang, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownLanguage, s)
+}
+
+// Label zwraca etykietę rekordu w podanym języku
+func (n Nomenclature) Label(lang Language) string {
+	switch lang {
+	case LangDE:
+		return n.DELabel
+	case LangEN:
+		return n.ENLabel
+	case LangES:
+		return n.ESLabel
+	case LangFR:
+		return n.FRLabel
+	case LangPT:
+		return n.PTLabel
+	}
+	return ""
+}
+
+// foldedLetters zamienia litery ze znakami diakrytycznymi na ich odpowiedniki
+// bez akcentów; obejmuje litery używane w etykietach DE, ES, FR i PT
+var foldedLetters = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+	'ą': "a", 'ć': "c", 'ę': "e", 'ł': "l", 'ń': "n", 'ś': "s", 'ź': "z", 'ż': "z",
+}
+
+// foldDiacritics zamienia tekst na małe litery bez znaków diakrytycznych
+func foldDiacritics(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if folded, ok := foldedLetters[r]; ok {
+			sb.WriteString(folded)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// tokenize dzieli tekst na słowa po usunięciu diakrytyków. Apostrofy łączą
+// słowa ("d'arachide" daje "darachide"), ale części są też zwracane osobno,
+// żeby zapytanie "arachide" również pasowało.
+func tokenize(s string) []string {
+	var tokens []string
+	words := strings.FieldsFunc(foldDiacritics(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+	for _, word := range words {
+		parts := strings.FieldsFunc(word, func(r rune) bool { return r == '\'' || r == '’' })
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) > 1 {
+			tokens = append(tokens, strings.Join(parts, ""))
+		}
+		tokens = append(tokens, parts...)
+	}
+	return tokens
+}
+
+// Parametry rankingu BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// waga dopasowania po prefiksie względem dopasowania całego słowa
+	prefixMatchWeight = 0.7
+	// domyślnie krótsze słowa zapytania nie są dopasowywane po prefiksie,
+	// bo pasowałyby do zbyt wielu słów etykiet
+	defaultMinPrefixLength = 2
+)
+
+type posting struct {
+	node *CPVNode
+	tf   int
+}
+
+// languageIndex to indeks odwrócony etykiet w jednym języku
+type languageIndex struct {
+	postings  map[string][]posting
+	terms     []string // posortowane, do wyszukiwania po prefiksie
+	docLength map[*CPVNode]int
+	avgLength float64
+}
+
+// SearchIndex to indeks pełnotekstowy etykiet CPV we wszystkich językach
+type SearchIndex struct {
+	tree      *CPVTree
+	languages map[Language]*languageIndex
+}
+
+// SearchOptions ogranicza i konfiguruje wyszukiwanie
+type SearchOptions struct {
+	Languages []Language // puste - wszystkie języki
+	Subtree   string     // kod, do którego poddrzewa zawęzić wyniki
+	Limit     int        // 0 - bez limitu
+	NoPrefix  bool       // wyłącza dopasowanie słów po prefiksie
+	// najkrótsze słowo zapytania dopasowywane po prefiksie; 0 - domyślnie 2,
+	// autouzupełnianie używa 1, żeby podpowiadać już po pierwszej literze
+	MinPrefixLength int
+}
+
+// SearchResult to znaleziony kod z oceną i językiem najlepszego dopasowania
+type SearchResult struct {
+	Node     *CPVNode
+	Score    float64
+	Language Language
+}
+
+// NewSearchIndex indeksuje etykiety wszystkich kodów drzewa
+func NewSearchIndex(tree *CPVTree) *SearchIndex {
+	idx := &SearchIndex{tree: tree, languages: make(map[Language]*languageIndex)}
+	for _, lang := range Languages {
+		li := &languageIndex{postings: make(map[string][]posting), docLength: make(map[*CPVNode]int)}
+		total := 0
+		for _, node := range tree.nodes {
+			tokens := tokenize(node.Label(lang))
+			if len(tokens) == 0 {
+				continue
+			}
+			counts := make(map[string]int)
+			for _, token := range tokens {
+				counts[token]++
+			}
+			for term, tf := range counts {
+				li.postings[term] = append(li.postings[term], posting{node: node, tf: tf})
+			}
+			li.docLength[node] = len(tokens)
+			total += len(tokens)
+		}
+		for term := range li.postings {
+			li.terms = append(li.terms, term)
+		}
+		sort.Strings(li.terms)
+		if len(li.docLength) > 0 {
+			li.avgLength = float64(total) / float64(len(li.docLength))
+		}
+		idx.languages[lang] = li
+	}
+	return idx
+}
+
+// matchingTerms zwraca słowa indeksu pasujące do słowa zapytania wraz z wagą.
+// Po prefiksie dopasowywane są słowa zapytania mające co najmniej minPrefix
+// znaków; 0 wyłącza dopasowanie po prefiksie.
+func (li *languageIndex) matchingTerms(queryTerm string, minPrefix int) map[string]float64 {
+	matches := make(map[string]float64)
+	if _, ok := li.postings[queryTerm]; ok {
+		matches[queryTerm] = 1
+	}
+	if minPrefix == 0 || len(queryTerm) < minPrefix {
+		return matches
+	}
+	for i := sort.SearchStrings(li.terms, queryTerm); i < len(li.terms) && strings.HasPrefix(li.terms[i], queryTerm); i++ {
+		if li.terms[i] != queryTerm {
+			matches[li.terms[i]] = prefixMatchWeight
+		}
+	}
+	return matches
+}
+
+// bm25 liczy wkład jednego słowa do oceny dokumentu
+func (li *languageIndex) bm25(df int, p posting) float64 {
+	n := float64(len(li.docLength))
+	idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+	tf := float64(p.tf)
+	norm := 1 - bm25B + bm25B*float64(li.docLength[p.node])/li.avgLength
+	return idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+}
+
+// scoreTerms liczy ocenę BM25 dla każdego kodu; matched zlicza, ile słów
+// zapytania zostało znalezionych w etykiecie
+func (li *languageIndex) scoreTerms(queryTerms []string, minPrefix int) (map[*CPVNode]float64, map[*CPVNode]int) {
+	scores := make(map[*CPVNode]float64)
+	matched := make(map[*CPVNode]int)
+	for _, queryTerm := range queryTerms {
+		best := make(map[*CPVNode]float64)
+		for term, weight := range li.matchingTerms(queryTerm, minPrefix) {
+			postings := li.postings[term]
+			for _, p := range postings {
+				score := weight * li.bm25(len(postings), p)
+				if score > best[p.node] {
+					best[p.node] = score
+				}
+			}
+		}
+		for node, score := range best {
+			scores[node] += score
+			matched[node]++
+		}
+	}
+	return scores, matched
+}
+
+// Search wyszukuje kody, których etykieta w którymś z języków zawiera
+// wszystkie słowa zapytania (słowa mogą też pasować po prefiksie).
+// Wyniki są posortowane malejąco według oceny BM25.
+func (idx *SearchIndex) Search(query string, opts SearchOptions) ([]SearchResult, error) {
+	queryTerms := tokenize(query)
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+
+	var root *CPVNode
+	if opts.Subtree != "" {
+		var err error
+		if root, err = idx.tree.Node(opts.Subtree); err != nil {
+			return nil, err
+		}
+	}
+	languages := opts.Languages
+	if len(languages) == 0 {
+		languages = Languages
+	}
+	minPrefix := opts.MinPrefixLength
+	if minPrefix == 0 {
+		minPrefix = defaultMinPrefixLength
+	}
+	if opts.NoPrefix {
+		minPrefix = 0
+	}
+
+	best := make(map[*CPVNode]SearchResult)
+	for _, lang := range languages {
+		li, ok := idx.languages[lang]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownLanguage, lang)
+		}
+		scores, matched := li.scoreTerms(queryTerms, minPrefix)
+		for node, score := range scores {
+			if matched[node] < len(queryTerms) {
+				continue
+			}
+			if root != nil && !node.InSubtree(root) {
+				continue
+			}
+			if score > best[node].Score {
+				best[node] = SearchResult{Node: node, Score: score, Language: lang}
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(best))
+	for _, result := range best {
+		results = append(results, result)
+	}
+	sortResults(results)
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results, nil
+}
+
+// sortResults sortuje malejąco według oceny, a przy remisie wyżej stawia
+// kody ogólniejsze, a potem niższe numerycznie
+func sortResults(results []SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Node.Level != results[j].Node.Level {
+			return results[i].Node.Level < results[j].Node.Level
+		}
+		return results[i].Node.ShortCode < results[j].Node.ShortCode
+	})
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// searchFixture buduje indeks z kilku kodów z etykietami w różnych językach
+func searchFixture(t *testing.T) *SearchIndex {
+	t.Helper()
+	labelled := func(shortCode string, labels map[Language]string) Nomenclature {
+		item := fixtureNomenclature(shortCode, labels[LangEN])
+		item.DELabel, item.ESLabel, item.FRLabel = labels[LangDE], labels[LangES], labels[LangFR]
+		return item
+	}
+	tree, err := BuildCPVTree([]Nomenclature{
+		labelled("03000000", map[Language]string{LangEN: "Agricultural products", LangFR: "Produits agricoles"}),
+		labelled("03111000", map[Language]string{LangEN: "Seeds", LangFR: "Graines"}),
+		labelled("03111100", map[Language]string{LangEN: "Seed potatoes", LangFR: "Plants de pommes de terre"}),
+		labelled("03111200", map[Language]string{LangEN: "Peanuts", LangFR: "Graines d'arachide", LangDE: "Erdnüsse"}),
+		labelled("03111600", map[Language]string{LangEN: "Mustard seeds", LangFR: "Graines de moutarde"}),
+		labelled("03111900", map[Language]string{LangEN: "Bulb seeds", LangFR: "Graines de bulbes"}),
+		labelled("15000000", map[Language]string{LangEN: "Food, beverages, tobacco", LangDE: "Nahrungsmittel, Getränke, Tabak"}),
+		labelled("15500000", map[Language]string{LangEN: "Dairy products", LangFR: "Crème fraîche", LangDE: "Süßrahm"}),
+		labelled("15800000", map[Language]string{LangEN: "Miscellaneous food products", LangES: "Productos alimenticios diversos"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSearchIndex(tree)
+}
+
+func resultCodes(results []SearchResult) string {
+	codes := make([]string, 0, len(results))
+	for _, result := range results {
+		codes = append(codes, result.Node.ShortCode)
+	}
+	return strings.Join(codes, " ")
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text   string
+		tokens string
+	}{
+		{"Peanuts", "peanuts"},
+		{"Erdnüsse", "erdnusse"},
+		{"Süßrahm", "sussrahm"},
+		{"Crème fraîche", "creme fraiche"},
+		{"ÉTÉ, Œuf; año", "ete oeuf ano"},
+		{"Łódź", "lodz"},
+		{"Graines d'arachide", "graines darachide d arachide"},
+		{"l’huile", "lhuile l huile"},
+		{"'quoted'", "quoted"},
+		{"' ''", ""},
+		{"Code 03111200-4", "code 03111200 4"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tokenize(tt.text), " "); got != tt.tokens {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.tokens)
+		}
+	}
+}
+
+func TestSearchDiacritics(t *testing.T) {
+	idx := searchFixture(t)
+	tests := []struct {
+		query string
+		lang  Language
+		want  string
+	}{
+		{"erdnusse", LangDE, "03111200"},
+		{"ERDNÜSSE", LangDE, "03111200"},
+		{"süssrahm", LangDE, "15500000"},
+		{"creme fraiche", LangFR, "15500000"},
+		{"crème", LangFR, "15500000"},
+		{"getranke", LangDE, "15000000"},
+	}
+	for _, tt := range tests {
+		results, err := idx.Search(tt.query, SearchOptions{Languages: []Language{tt.lang}, NoPrefix: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultCodes(results); got != tt.want {
+			t.Errorf("Search(%q, %s) = %q, want %q", tt.query, tt.lang, got, tt.want)
+		}
+		if len(results) > 0 && results[0].Language != tt.lang {
+			t.Errorf("Search(%q): language %s, want %s", tt.query, results[0].Language, tt.lang)
+		}
+	}
+}
+
+func TestSearchApostrophes(t *testing.T) {
+	idx := searchFixture(t)
+	for _, query := range []string{"arachide", "darachide", "d'arachide", "d’arachide", "graines d arachide"} {
+		results, err := idx.Search(query, SearchOptions{Languages: []Language{LangFR}, NoPrefix: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultCodes(results); got != "03111200" {
+			t.Errorf("Search(%q) = %q, want 03111200", query, got)
+		}
+	}
+}
+
+func TestSearchPrefix(t *testing.T) {
+	idx := searchFixture(t)
+	tests := []struct {
+		query string
+		opts  SearchOptions
+		want  string
+	}{
+		{"musta", SearchOptions{}, "03111600"},
+		{"musta", SearchOptions{NoPrefix: true}, ""},
+		{"mustard se", SearchOptions{}, "03111600"},
+		{"pean", SearchOptions{Languages: []Language{LangDE}}, ""},
+		// jedna litera nie jest domyślnie dopasowywana po prefiksie
+		{"m", SearchOptions{}, ""},
+		{"m", SearchOptions{Languages: []Language{LangEN}, MinPrefixLength: 1}, "03111600 15800000"},
+		{"m", SearchOptions{Languages: []Language{LangEN}, MinPrefixLength: 1, NoPrefix: true}, ""},
+	}
+	for _, tt := range tests {
+		results, err := idx.Search(tt.query, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Fields(resultCodes(results))
+		slices.Sort(got)
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("Search(%q, %+v) = %q, want %q", tt.query, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := searchFixture(t)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// krótsza etykieta wyżej; przy remisie ogólniejszy, potem niższy kod
+		{"seeds", "03111000 03111600 03111900"},
+		// całe słowo "seed" przed dopasowaniami po prefiksie
+		{"seed", "03111100 03111000 03111600 03111900"},
+		{"products", "03000000 15500000 15800000"},
+	}
+	for _, tt := range tests {
+		results, err := idx.Search(tt.query, SearchOptions{Languages: []Language{LangEN}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resultCodes(results); got != tt.want {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+		for i := 1; i < len(results); i++ {
+			if results[i].Score > results[i-1].Score {
+				t.Errorf("Search(%q): result %d scored %f above result %d (%f)",
+					tt.query, i, results[i].Score, i-1, results[i-1].Score)
+			}
+		}
+	}
+
+	results, _ := idx.Search("seeds", SearchOptions{Languages: []Language{LangEN}, Limit: 2})
+	if got := resultCodes(results); got != "03111000 03111600" {
+		t.Errorf("Search(seeds, limit 2) = %q, want 03111000 03111600", got)
+	}
+}
+
+func TestSearchSubtree(t *testing.T) {
+	idx := searchFixture(t)
+	tests := []struct {
+		query   string
+		subtree string
+		want    string
+	}{
+		{"products", "15000000", "15500000 15800000"},
+		{"products", "15500000-0", "15500000"},
+		{"products", "03111000", ""},
+		{"graines", "03111000", "03111000 03111600 03111900 03111200"},
+	}
+	for _, tt := range tests {
+		results, err := idx.Search(tt.query, SearchOptions{Subtree: tt.subtree})
+		if err != nil {
+			t.Fatalf("Search(%q, subtree %s): %v", tt.query, tt.subtree, err)
+		}
+		if got := resultCodes(results); got != tt.want {
+			t.Errorf("Search(%q, subtree %s) = %q, want %q", tt.query, tt.subtree, got, tt.want)
+		}
+	}
+
+	if _, err := idx.Search("seeds", SearchOptions{Subtree: "99000000"}); !errors.Is(err, ErrUnknownCPVCode) {
+		t.Errorf("unknown subtree: %v, want %v", err, ErrUnknownCPVCode)
+	}
+	if _, err := idx.Search("seeds", SearchOptions{Languages: []Language{"xx"}}); !errors.Is(err, ErrUnknownLanguage) {
+		t.Errorf("unknown language: %v, want %v", err, ErrUnknownLanguage)
+	}
+	if results, err := idx.Search(" ,' ", SearchOptions{}); err != nil || len(results) != 0 {
+		t.Errorf("empty query: %v, %v", resultCodes(results), err)
+	}
+}
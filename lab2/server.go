@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Limity liczby wyników zwracanych przez API
+const (
+	defaultSearchLimit       = 20
+	defaultAutocompleteLimit = 10
+	maxResultLimit           = 100
+)
+
+// Kody błędów zwracane w polu "code" odpowiedzi z błędem
+const (
+	errCodeNotFound      = "not_found"
+	errCodeBadParameters = "bad_parameters"
+)
+
+type apiNode struct {
+	Code      string              `json:"code"`
+	ShortCode string              `json:"short_code"`
+	Level     int                 `json:"level"`
+	Labels    map[Language]string `json:"labels"`
+}
+
+type apiNodeDetails struct {
+	apiNode
+	Parent    *apiNode  `json:"parent"`
+	Ancestors []apiNode `json:"ancestors"`
+	Children  []apiNode `json:"children"`
+}
+
+type apiSearchResult struct {
+	apiNode
+	Score    float64  `json:"score"`
+	Language Language `json:"language"`
+}
+
+type apiSuggestion struct {
+	Code      string `json:"code"`
+	ShortCode string `json:"short_code"`
+	Label     string `json:"label"`
+}
+
+type apiError struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// CPVServer udostępnia słownik CPV przez HTTP. Drzewo i indeks są budowane raz
+// przy starcie i tylko odczytywane, więc handlery nie potrzebują blokad.
+type CPVServer struct {
+	tree  *CPVTree
+	index *SearchIndex
+	codes []*CPVNode // posortowane według short_code, do podpowiedzi po kodzie
+}
+
+// NewCPVServer tworzy serwer dla wczytanego drzewa CPV
+func NewCPVServer(tree *CPVTree) *CPVServer {
+	s := &CPVServer{tree: tree, index: NewSearchIndex(tree), codes: make([]*CPVNode, 0, tree.Len())}
+	for _, node := range tree.nodes {
+		s.codes = append(s.codes, node)
+	}
+	sortNodes(s.codes)
+	return s
+}
+
+// Handler zwraca handler HTTP z endpointami:
+// GET /cpv/{code}, GET /cpv/search?q=&lang=&limit=&subtree= oraz
+// GET /cpv/autocomplete?prefix=&lang=&limit=
+func (s *CPVServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /cpv/search", s.handleSearch)
+	mux.HandleFunc("GET /cpv/autocomplete", s.handleAutocomplete)
+	mux.HandleFunc("GET /cpv/{code}", s.handleNode)
+	return mux
+}
+
+func newAPINode(node *CPVNode) apiNode {
+	labels := make(map[Language]string, len(Languages))
+	for _, lang := range Languages {
+		labels[lang] = node.Label(lang)
+	}
+	return apiNode{Code: node.Code, ShortCode: node.ShortCode, Level: node.Level, Labels: labels}
+}
+
+func newAPINodes(nodes []*CPVNode) []apiNode {
+	result := make([]apiNode, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, newAPINode(node))
+	}
+	return result
+}
+
+func (s *CPVServer) handleNode(w http.ResponseWriter, r *http.Request) {
+	node, err := s.tree.Node(r.PathValue("code"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, errCodeNotFound, err.Error())
+		return
+	}
+	ancestors, _ := s.tree.Ancestors(node.ShortCode)
+	details := apiNodeDetails{
+		apiNode:   newAPINode(node),
+		Ancestors: newAPINodes(ancestors),
+		Children:  newAPINodes(node.Children),
+	}
+	if node.Parent != nil {
+		parent := newAPINode(node.Parent)
+		details.Parent = &parent
+	}
+	writeCachedJSON(w, r, details)
+}
+
+func (s *CPVServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	languages, err := parseLanguages(query.Get("lang"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeBadParameters, err.Error())
+		return
+	}
+	limit, err := parseLimit(query.Get("limit"), defaultSearchLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeBadParameters, err.Error())
+		return
+	}
+	if strings.TrimSpace(query.Get("q")) == "" {
+		writeError(w, http.StatusBadRequest, errCodeBadParameters, "brak parametru q")
+		return
+	}
+
+	results, err := s.index.Search(query.Get("q"), SearchOptions{Languages: languages, Subtree: query.Get("subtree"), Limit: limit})
+	if err != nil {
+		status, code := http.StatusBadRequest, errCodeBadParameters
+		if errors.Is(err, ErrUnknownCPVCode) {
+			status, code = http.StatusNotFound, errCodeNotFound
+		}
+		writeError(w, status, code, err.Error())
+		return
+	}
+	response := make([]apiSearchResult, 0, len(results))
+	for _, result := range results {
+		response = append(response, apiSearchResult{apiNode: newAPINode(result.Node), Score: result.Score, Language: result.Language})
+	}
+	writeCachedJSON(w, r, response)
+}
+
+// handleAutocomplete podpowiada kody: prefiks złożony z cyfr jest dopasowywany
+// do kodu, a tekst do słów etykiet w wybranym języku (domyślnie EN), już od
+// jednej litery
+func (s *CPVServer) handleAutocomplete(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lang := LangEN
+	if query.Get("lang") != "" {
+		var err error
+		if lang, err = ParseLanguage(query.Get("lang")); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeBadParameters, err.Error())
+			return
+		}
+	}
+	limit, err := parseLimit(query.Get("limit"), defaultAutocompleteLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errCodeBadParameters, err.Error())
+		return
+	}
+	prefix := strings.TrimSpace(query.Get("prefix"))
+	if prefix == "" {
+		writeError(w, http.StatusBadRequest, errCodeBadParameters, "brak parametru prefix")
+		return
+	}
+
+	var nodes []*CPVNode
+	if isDigits(prefix) {
+		nodes = s.codesWithPrefix(prefix, limit)
+	} else {
+		results, err := s.index.Search(prefix, SearchOptions{Languages: []Language{lang}, Limit: limit, MinPrefixLength: 1})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeBadParameters, err.Error())
+			return
+		}
+		for _, result := range results {
+			nodes = append(nodes, result.Node)
+		}
+	}
+
+	suggestions := make([]apiSuggestion, 0, len(nodes))
+	for _, node := range nodes {
+		suggestions = append(suggestions, apiSuggestion{Code: node.Code, ShortCode: node.ShortCode, Label: node.Label(lang)})
+	}
+	writeCachedJSON(w, r, suggestions)
+}
+
+// codesWithPrefix zwraca najwyżej limit kodów zaczynających się od prefiksu,
+// ogólniejsze kody przed szczegółowymi
+func (s *CPVServer) codesWithPrefix(prefix string, limit int) []*CPVNode {
+	var nodes []*CPVNode
+	start := sort.Search(len(s.codes), func(i int) bool { return s.codes[i].ShortCode >= prefix })
+	for i := start; i < len(s.codes) && strings.HasPrefix(s.codes[i].ShortCode, prefix); i++ {
+		nodes = append(nodes, s.codes[i])
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Level < nodes[j].Level })
+	return nodes[:min(limit, len(nodes))]
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLimit odczytuje parametr limit z zakresu 1-maxResultLimit
+func parseLimit(value string, defaultLimit int) (int, error) {
+	if value == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxResultLimit {
+		return 0, fmt.Errorf("parametr limit musi być liczbą z zakresu 1-%d", maxResultLimit)
+	}
+	return limit, nil
+}
+
+// writeCachedJSON wysyła odpowiedź z nagłówkiem ETag wyliczonym z jej treści.
+// Słownik się nie zmienia, więc ta sama treść ma zawsze ten sam ETag, a klient
+// z pasującym If-None-Match dostaje 304 bez treści.
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, v any) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// etagMatches sprawdza nagłówek If-None-Match, który może zawierać listę
+// znaczników, znaczniki słabe (W/"...") albo "*"
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiError{Code: code, Error: message})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testHandler buduje serwer ze słownika dołączonego do repozytorium raz
+// dla wszystkich testów
+var testHandler = sync.OnceValues(func() (http.Handler, error) {
+	_, tree, err := loadTree(defaultNomenclaturePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewCPVServer(tree).Handler(), nil
+})
+
+// get wysyła zapytanie GET z opcjonalnymi nagłówkami i zwraca odpowiedź
+func get(t *testing.T, target string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	handler, err := testHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// getJSON wysyła zapytanie GET, sprawdza status i dekoduje odpowiedź do v
+func getJSON(t *testing.T, target string, wantStatus int, v any) {
+	t.Helper()
+	rec := get(t, target, nil)
+	if rec.Code != wantStatus {
+		t.Fatalf("GET %s: status %d, want %d (body %s)", target, rec.Code, wantStatus, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("GET %s: invalid JSON %q: %v", target, rec.Body.String(), err)
+	}
+}
+
+func shortCodes(nodes []apiNode) []string {
+	codes := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		codes = append(codes, node.ShortCode)
+	}
+	return codes
+}
+
+func TestNodeAncestors(t *testing.T) {
+	var details apiNodeDetails
+	getJSON(t, "/cpv/03111200-4", http.StatusOK, &details)
+	if details.Code != "03111200-4" || details.Labels[LangEN] != "Peanuts" || details.Labels[LangFR] != "Graines d'arachide" {
+		t.Errorf("got %+v, want 03111200-4 Peanuts", details.apiNode)
+	}
+	wantAncestors := "03000000 03100000 03110000 03111000"
+	if got := strings.Join(shortCodes(details.Ancestors), " "); got != wantAncestors {
+		t.Errorf("ancestors %s, want %s", got, wantAncestors)
+	}
+	if details.Parent == nil || details.Parent.ShortCode != "03111000" {
+		t.Errorf("parent %+v, want 03111000", details.Parent)
+	}
+	if len(details.Children) != 0 {
+		t.Errorf("leaf code has children %v", shortCodes(details.Children))
+	}
+
+	// kod można podać także bez cyfry kontrolnej
+	var short apiNodeDetails
+	getJSON(t, "/cpv/03111200", http.StatusOK, &short)
+	if short.Code != details.Code {
+		t.Errorf("short code resolved to %s, want %s", short.Code, details.Code)
+	}
+}
+
+func TestNodeChildren(t *testing.T) {
+	var details apiNodeDetails
+	getJSON(t, "/cpv/03000000-1", http.StatusOK, &details)
+	if details.Parent != nil || len(details.Ancestors) != 0 {
+		t.Errorf("division has parent %+v and ancestors %v", details.Parent, shortCodes(details.Ancestors))
+	}
+	if len(details.Children) == 0 {
+		t.Fatal("division 03000000 has no children")
+	}
+	for _, child := range details.Children {
+		var childDetails apiNodeDetails
+		getJSON(t, "/cpv/"+child.Code, http.StatusOK, &childDetails)
+		if childDetails.Parent == nil || childDetails.Parent.ShortCode != "03000000" || child.Level != details.Level+1 {
+			t.Errorf("child %s (level %d) has parent %+v, want 03000000 at level %d",
+				child.Code, child.Level, childDetails.Parent, details.Level+1)
+		}
+	}
+}
+
+func TestNodeNotFound(t *testing.T) {
+	var apiErr apiError
+	getJSON(t, "/cpv/99999999", http.StatusNotFound, &apiErr)
+	if apiErr.Code != errCodeNotFound {
+		t.Errorf("error code %q, want %q", apiErr.Code, errCodeNotFound)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var results []apiSearchResult
+	getJSON(t, "/cpv/search?q=peanuts", http.StatusOK, &results)
+	if len(results) == 0 || results[0].ShortCode != "03111200" || results[0].Language != LangEN {
+		t.Fatalf("search peanuts: got %+v, want 03111200 first", results)
+	}
+
+	getJSON(t, "/cpv/search?q=arachide&lang=fr", http.StatusOK, &results)
+	found := false
+	for _, result := range results {
+		found = found || result.ShortCode == "03111200"
+		if result.Language != LangFR {
+			t.Errorf("search lang=fr returned a %s match for %s", result.Language, result.Code)
+		}
+	}
+	if !found {
+		t.Errorf("search arachide in fr did not find 03111200")
+	}
+
+	getJSON(t, "/cpv/search?q=seeds&subtree=03111000&limit=5", http.StatusOK, &results)
+	if len(results) == 0 || len(results) > 5 {
+		t.Fatalf("search in subtree returned %d results, want 1-5", len(results))
+	}
+	for _, result := range results {
+		if !strings.HasPrefix(result.ShortCode, "03111") {
+			t.Errorf("search in subtree 03111000 returned %s", result.Code)
+		}
+	}
+
+	tests := []struct {
+		target string
+		status int
+		code   string
+	}{
+		{"/cpv/search", http.StatusBadRequest, errCodeBadParameters},
+		{"/cpv/search?q=seeds&lang=xx", http.StatusBadRequest, errCodeBadParameters},
+		{"/cpv/search?q=seeds&limit=0", http.StatusBadRequest, errCodeBadParameters},
+		{"/cpv/search?q=seeds&subtree=99999999", http.StatusNotFound, errCodeNotFound},
+	}
+	for _, tt := range tests {
+		var apiErr apiError
+		getJSON(t, tt.target, tt.status, &apiErr)
+		if apiErr.Code != tt.code {
+			t.Errorf("GET %s: error code %q, want %q", tt.target, apiErr.Code, tt.code)
+		}
+	}
+}
+
+func TestAutocompleteCode(t *testing.T) {
+	var suggestions []apiSuggestion
+	getJSON(t, "/cpv/autocomplete?prefix=0311&limit=3", http.StatusOK, &suggestions)
+	if len(suggestions) != 3 {
+		t.Fatalf("got %d suggestions, want 3", len(suggestions))
+	}
+	// ogólniejsze kody przed szczegółowymi
+	if suggestions[0].ShortCode != "03110000" {
+		t.Errorf("first suggestion %s, want 03110000", suggestions[0].ShortCode)
+	}
+	for _, suggestion := range suggestions {
+		if !strings.HasPrefix(suggestion.ShortCode, "0311") {
+			t.Errorf("suggestion %s does not start with 0311", suggestion.ShortCode)
+		}
+	}
+}
+
+// TestAutocompleteText sprawdza podpowiedzi po słowach etykiet, także dla
+// prefiksu z jednej litery, który musi pasować do początku słów, a nie
+// tylko do słowa "m"
+func TestAutocompleteText(t *testing.T) {
+	tests := []struct {
+		prefix string
+		lang   Language
+		min    int // najmniejsza oczekiwana liczba podpowiedzi
+	}{
+		{"m", LangEN, defaultAutocompleteLimit},
+		{"s", LangDE, defaultAutocompleteLimit},
+		{"pea", LangEN, 2},
+		{"arach", LangFR, 2},
+	}
+	for _, tt := range tests {
+		var suggestions []apiSuggestion
+		getJSON(t, "/cpv/autocomplete?prefix="+tt.prefix+"&lang="+string(tt.lang), http.StatusOK, &suggestions)
+		if len(suggestions) < tt.min || len(suggestions) > defaultAutocompleteLimit {
+			t.Errorf("prefix %q: got %d suggestions, want %d-%d", tt.prefix, len(suggestions), tt.min, defaultAutocompleteLimit)
+		}
+		for _, suggestion := range suggestions {
+			matches := false
+			for _, token := range tokenize(suggestion.Label) {
+				matches = matches || strings.HasPrefix(token, tt.prefix)
+			}
+			if !matches {
+				t.Errorf("prefix %q: suggestion %s %q has no word with this prefix", tt.prefix, suggestion.Code, suggestion.Label)
+			}
+		}
+	}
+
+	var apiErr apiError
+	getJSON(t, "/cpv/autocomplete", http.StatusBadRequest, &apiErr)
+	if apiErr.Code != errCodeBadParameters {
+		t.Errorf("missing prefix: error code %q, want %q", apiErr.Code, errCodeBadParameters)
+	}
+}
+
+func TestETag(t *testing.T) {
+	for _, target := range []string{"/cpv/03111200-4", "/cpv/search?q=peanuts", "/cpv/autocomplete?prefix=pea"} {
+		first := get(t, target, nil)
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" {
+			t.Fatalf("GET %s: status %d, ETag %q", target, first.Code, etag)
+		}
+		if second := get(t, target, nil); second.Header().Get("ETag") != etag {
+			t.Errorf("GET %s: ETag changed from %s to %s", target, etag, second.Header().Get("ETag"))
+		}
+
+		tests := []struct {
+			ifNoneMatch string
+			status      int
+		}{
+			{etag, http.StatusNotModified},
+			{"W/" + etag, http.StatusNotModified},
+			{`"other", ` + etag, http.StatusNotModified},
+			{"*", http.StatusNotModified},
+			{`"other"`, http.StatusOK},
+		}
+		for _, tt := range tests {
+			rec := get(t, target, http.Header{"If-None-Match": {tt.ifNoneMatch}})
+			if rec.Code != tt.status {
+				t.Errorf("GET %s with If-None-Match %s: status %d, want %d", target, tt.ifNoneMatch, rec.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && rec.Body.Len() > 0 {
+				t.Errorf("GET %s: 304 response has a body %q", target, rec.Body.String())
+			}
+		}
+	}
+}
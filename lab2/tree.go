@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CPVNode to węzeł drzewa CPV: rekord słownika wraz z rodzicem i dziećmi
+type CPVNode struct {
+	Nomenclature
+	CPV      CPVCode
+	Level    int // 1 - dział, 2 - grupa, 3 - klasa, 4 - kategoria, 5-7 - podkategorie
+	Parent   *CPVNode
+	Children []*CPVNode
+}
+
+// CPVTree przechowuje hierarchię kodów CPV zbudowaną na podstawie cyfr kodu
+type CPVTree struct {
+	nodes map[string]*CPVNode // klucz to short_code
+	Roots []*CPVNode          // działy (oraz kody, dla których nie ma żadnego przodka)
+}
+
+// codeLevel wyznacza poziom kodu z liczby cyfr znaczących: 03000000 to dział
+// (poziom 1), 03100000 grupa (poziom 2) itd.
+func codeLevel(digits [8]int) int {
+	significant := 2
+	for i := 7; i >= 2; i-- {
+		if digits[i] != 0 {
+			significant = i + 1
+			break
+		}
+	}
+	return significant - 1
+}
+
+// BuildCPVTree buduje drzewo z rekordów słownika. Rodzicem kodu jest najbliższy
+// istniejący kod powstały przez zerowanie kolejnych cyfr od końca.
+func BuildCPVTree(nomenclatures []Nomenclature) (*CPVTree, error) {
+	tree := &CPVTree{nodes: make(map[string]*CPVNode, len(nomenclatures))}
+	for _, item := range nomenclatures {
+		code, err := item.ParseCode()
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := tree.nodes[code.ShortCode()]; exists {
+			return nil, fmt.Errorf("powtórzony kod CPV: %s", code)
+		}
+		tree.nodes[code.ShortCode()] = &CPVNode{Nomenclature: item, CPV: code, Level: codeLevel(code.Digits)}
+	}
+
+	for _, node := range tree.nodes {
+		node.Parent = tree.findParent(node.CPV.Digits)
+		if node.Parent == nil {
+			tree.Roots = append(tree.Roots, node)
+		} else {
+			node.Parent.Children = append(node.Parent.Children, node)
+		}
+	}
+
+	sortNodes(tree.Roots)
+	for _, node := range tree.nodes {
+		sortNodes(node.Children)
+	}
+	return tree, nil
+}
+
+func (t *CPVTree) findParent(digits [8]int) *CPVNode {
+	for i := codeLevel(digits); i >= 2; i-- {
+		if digits[i] == 0 {
+			continue
+		}
+		digits[i] = 0
+		if parent, ok := t.nodes[CPVCode{Digits: digits}.ShortCode()]; ok {
+			return parent
+		}
+	}
+	return nil
+}
+
+func sortNodes(nodes []*CPVNode) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ShortCode < nodes[j].ShortCode })
+}
+
+// Len zwraca liczbę kodów w drzewie
+func (t *CPVTree) Len() int { return len(t.nodes) }
+
+// Node wyszukuje węzeł po kodzie w postaci "03111200-4" albo "03111200"
+func (t *CPVTree) Node(code string) (*CPVNode, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == 10 && code[8] == '-' {
+		code = code[:8]
+	}
+	node, ok := t.nodes[code]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCPVCode, code)
+	}
+	return node, nil
+}
+
+// Parent zwraca rodzica kodu albo nil dla działu
+func (t *CPVTree) Parent(code string) (*CPVNode, error) {
+	node, err := t.Node(code)
+	if err != nil {
+		return nil, err
+	}
+	return node.Parent, nil
+}
+
+// Children zwraca bezpośrednie dzieci kodu posortowane według kodu
+func (t *CPVTree) Children(code string) ([]*CPVNode, error) {
+	node, err := t.Node(code)
+	if err != nil {
+		return nil, err
+	}
+	return node.Children, nil
+}
+
+// Ancestors zwraca przodków kodu od działu do rodzica (kolejność okruszków)
+func (t *CPVTree) Ancestors(code string) ([]*CPVNode, error) {
+	node, err := t.Node(code)
+	if err != nil {
+		return nil, err
+	}
+	var ancestors []*CPVNode
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		ancestors = append([]*CPVNode{parent}, ancestors...)
+	}
+	return ancestors, nil
+}
+
+// Subtree zwraca kod i wszystkich jego potomków w kolejności przechodzenia w głąb
+func (t *CPVTree) Subtree(code string) ([]*CPVNode, error) {
+	node, err := t.Node(code)
+	if err != nil {
+		return nil, err
+	}
+	var subtree []*CPVNode
+	var walk func(*CPVNode)
+	walk = func(n *CPVNode) {
+		subtree = append(subtree, n)
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(node)
+	return subtree, nil
+}
+
+// InSubtree sprawdza, czy węzeł leży w poddrzewie kodu root (lub jest nim samym)
+func (n *CPVNode) InSubtree(root *CPVNode) bool {
+	for node := n; node != nil; node = node.Parent {
+		if node == root {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fixtureNomenclature tworzy rekord z wyliczoną cyfrą kontrolną
+func fixtureNomenclature(shortCode, label string) Nomenclature {
+	code, err := ParseCPVCode(shortCode + "-0")
+	if err != nil {
+		panic(err)
+	}
+	code.CheckDigit = ComputeCPVCheckDigit(code.Digits)
+	return Nomenclature{Code: code.String(), ShortCode: shortCode, ENLabel: label}
+}
+
+// fixtureTree buduje małe drzewo: kod 03221000 nie ma klasy 03220000,
+// a 05110000 nie ma swojego działu
+func fixtureTree(t *testing.T) *CPVTree {
+	t.Helper()
+	tree, err := BuildCPVTree([]Nomenclature{
+		fixtureNomenclature("03111600", "Mustard seeds"),
+		fixtureNomenclature("03000000", "Agricultural products"),
+		fixtureNomenclature("03111200", "Peanuts"),
+		fixtureNomenclature("03100000", "Agricultural and horticultural products"),
+		fixtureNomenclature("03110000", "Crops"),
+		fixtureNomenclature("03111000", "Seeds"),
+		fixtureNomenclature("03200000", "Cereals"),
+		fixtureNomenclature("03221000", "Vegetables"),
+		fixtureNomenclature("05110000", "Orphaned class"),
+		fixtureNomenclature("15000000", "Food"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func nodeCodes(nodes []*CPVNode) string {
+	codes := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		codes = append(codes, node.ShortCode)
+	}
+	return strings.Join(codes, " ")
+}
+
+func TestCPVTreeParent(t *testing.T) {
+	tree := fixtureTree(t)
+	if tree.Len() != 10 {
+		t.Errorf("Len() = %d, want 10", tree.Len())
+	}
+	if got := nodeCodes(tree.Roots); got != "03000000 05110000 15000000" {
+		t.Errorf("roots %s, want 03000000 05110000 15000000", got)
+	}
+
+	tests := []struct {
+		code   string
+		parent string // pusty dla korzenia
+		level  int
+	}{
+		{"03000000", "", 1},
+		{"03100000", "03000000", 2},
+		{"03110000", "03100000", 3},
+		{"03111000", "03110000", 4},
+		{"03111200-4", "03111000", 5},
+		{"03111200", "03111000", 5},
+		{"03221000", "03200000", 4}, // brak klasy 03220000
+		{"05110000", "", 3},         // brak działu 05000000
+	}
+	for _, tt := range tests {
+		parent, err := tree.Parent(tt.code)
+		if err != nil {
+			t.Fatalf("Parent(%s): %v", tt.code, err)
+		}
+		got := ""
+		if parent != nil {
+			got = parent.ShortCode
+		}
+		node, _ := tree.Node(tt.code)
+		if got != tt.parent || node.Level != tt.level {
+			t.Errorf("Parent(%s) = %q at level %d, want %q at level %d", tt.code, got, node.Level, tt.parent, tt.level)
+		}
+	}
+
+	for _, code := range []string{"99000000", "03111300", "031112"} {
+		if _, err := tree.Parent(code); !errors.Is(err, ErrUnknownCPVCode) {
+			t.Errorf("Parent(%s) = %v, want %v", code, err, ErrUnknownCPVCode)
+		}
+	}
+}
+
+func TestCPVTreeChildren(t *testing.T) {
+	tree := fixtureTree(t)
+	tests := []struct {
+		code     string
+		children string
+	}{
+		{"03000000", "03100000 03200000"},
+		{"03111000", "03111200 03111600"}, // posortowane mimo innej kolejności w pliku
+		{"03200000", "03221000"},
+		{"03111200", ""},
+		{"05110000", ""},
+	}
+	for _, tt := range tests {
+		children, err := tree.Children(tt.code)
+		if err != nil {
+			t.Fatalf("Children(%s): %v", tt.code, err)
+		}
+		if got := nodeCodes(children); got != tt.children {
+			t.Errorf("Children(%s) = %q, want %q", tt.code, got, tt.children)
+		}
+	}
+	if _, err := tree.Children("99000000"); !errors.Is(err, ErrUnknownCPVCode) {
+		t.Errorf("Children(99000000) = %v, want %v", err, ErrUnknownCPVCode)
+	}
+}
+
+func TestCPVTreeAncestors(t *testing.T) {
+	tree := fixtureTree(t)
+	tests := []struct {
+		code      string
+		ancestors string
+	}{
+		{"03111200", "03000000 03100000 03110000 03111000"},
+		{"03221000", "03000000 03200000"},
+		{"03000000", ""},
+		{"05110000", ""},
+	}
+	for _, tt := range tests {
+		ancestors, err := tree.Ancestors(tt.code)
+		if err != nil {
+			t.Fatalf("Ancestors(%s): %v", tt.code, err)
+		}
+		if got := nodeCodes(ancestors); got != tt.ancestors {
+			t.Errorf("Ancestors(%s) = %q, want %q", tt.code, got, tt.ancestors)
+		}
+	}
+	if _, err := tree.Ancestors("99000000"); !errors.Is(err, ErrUnknownCPVCode) {
+		t.Errorf("Ancestors(99000000) = %v, want %v", err, ErrUnknownCPVCode)
+	}
+}
+
+func TestCPVTreeSubtree(t *testing.T) {
+	tree := fixtureTree(t)
+	tests := []struct {
+		code    string
+		subtree string
+	}{
+		{"03000000", "03000000 03100000 03110000 03111000 03111200 03111600 03200000 03221000"},
+		{"03110000", "03110000 03111000 03111200 03111600"},
+		{"03111600", "03111600"},
+		{"05110000", "05110000"},
+	}
+	for _, tt := range tests {
+		subtree, err := tree.Subtree(tt.code)
+		if err != nil {
+			t.Fatalf("Subtree(%s): %v", tt.code, err)
+		}
+		if got := nodeCodes(subtree); got != tt.subtree {
+			t.Errorf("Subtree(%s) = %q, want %q", tt.code, got, tt.subtree)
+		}
+	}
+	if _, err := tree.Subtree("99000000"); !errors.Is(err, ErrUnknownCPVCode) {
+		t.Errorf("Subtree(99000000) = %v, want %v", err, ErrUnknownCPVCode)
+	}
+
+	root, _ := tree.Node("03100000")
+	for code, want := range map[string]bool{"03111200": true, "03100000": true, "03221000": false, "05110000": false} {
+		node, _ := tree.Node(code)
+		if got := node.InSubtree(root); got != want {
+			t.Errorf("%s.InSubtree(03100000) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestBuildCPVTreeErrors(t *testing.T) {
+	_, err := BuildCPVTree([]Nomenclature{fixtureNomenclature("03000000", "A"), fixtureNomenclature("03000000", "B")})
+	if err == nil || !strings.Contains(err.Error(), "03000000") {
+		t.Errorf("duplicate code: %v", err)
+	}
+	_, err = BuildCPVTree([]Nomenclature{{Code: "03000000-1", ShortCode: "03100000"}})
+	if !errors.Is(err, ErrShortCodeMismatch) {
+		t.Errorf("short_code mismatch: %v, want %v", err, ErrShortCodeMismatch)
+	}
+}
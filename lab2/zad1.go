@@ -1,71 +1,329 @@
 package main
 
 import (
-	"encoding/csv"
+	"flag"
 	"fmt"
-	"io"
-	"log"
+	"net/http"
 	"os"
-	"sort"
+	"strings"
+	"time"
 )
 
-type Nomenclature struct {
-	code       string
-	de_label   string
-	en_label   string
-	es_label   string
-	fr_label   string
-	pt_label   string
-	short_code string
+const defaultNomenclaturePath = "./nomenclature-cpv.csv"
+
+func main() {
+	// bez komendy program wypisuje podsumowanie słownika
+	if len(os.Args) < 2 {
+		printSummary(defaultNomenclaturePath)
+		return
+	}
+
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "diff":
+		runDiff(os.Args[2:])
+	case "migrate":
+		runMigrate(os.Args[2:])
+	case "serve":
+		runServe(os.Args[2:])
+	case "classify":
+		runClassify(os.Args[2:])
+	default:
+		fmt.Printf("Nieznana komenda: %s\n", os.Args[1])
+		printUsage()
+		os.Exit(1)
+	}
 }
 
-func readCSVLineByLine(filepath string) []string {
-	var accidents_slice []Nomenclature
+func printUsage() {
+	fmt.Println("Użycie: program [komenda] [flagi]")
+	fmt.Println("Komendy:")
+	fmt.Println("   (brak)      - Podsumowanie słownika CPV")
+	fmt.Println("   export      - Eksport słownika do JSON, JSON Lines, SQL lub TSV")
+	fmt.Println("   diff        - Różnice między dwiema wersjami słownika")
+	fmt.Println("   migrate     - Zamiana starych kodów CPV w kolumnie pliku CSV na nowe")
+	fmt.Println("   classify    - Propozycje kodów CPV dla opisu pozycji (-k, -eval)")
+	fmt.Println("   serve       - Serwer HTTP z wyszukiwaniem i podpowiedziami kodów CPV")
+}
 
-	// otwarcie pliku
-	file, err := os.Open(filepath)
+// loadTree wczytuje słownik i buduje z niego drzewo; błędne wiersze są
+// zgłaszane, ale nie przerywają pracy
+func loadTree(path string) ([]Nomenclature, *CPVTree, error) {
+	nomenclatures, err := LoadNomenclature(path)
 	if err != nil {
-		log.Fatal(err)
+		if len(nomenclatures) == 0 {
+			return nil, nil, err
+		}
+		fmt.Fprintln(os.Stderr, "Błąd wczytywania słownika CPV:", err)
+	}
+	tree, err := BuildCPVTree(nomenclatures)
+	if err != nil {
+		return nil, nil, fmt.Errorf("błąd budowania drzewa CPV: %w", err)
 	}
-	defer file.Close()
+	return nomenclatures, tree, nil
+}
 
-	reader := csv.NewReader(file)
-	//ustawienie separatora
-	reader.Comma = ';'
+// parseLanguages zamienia listę języków oddzielonych przecinkami na []Language
+func parseLanguages(list string) ([]Language, error) {
+	var languages []Language
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		lang, err := ParseLanguage(item)
+		if err != nil {
+			return nil, err
+		}
+		languages = append(languages, lang)
+	}
+	return languages, nil
+}
 
-	//pominiecie pierwszej lini (naglowek)
-	_, err = reader.Read()
+func runExport(args []string) {
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	path := exportCmd.String("file", defaultNomenclaturePath, "Plik słownika CPV")
+	format := exportCmd.String("format", "jsonl", "Format: json, jsonl, sql, tsv")
+	langs := exportCmd.String("lang", "", "Języki etykiet oddzielone przecinkami (domyślnie wszystkie)")
+	subtree := exportCmd.String("subtree", "", "Kod, którego poddrzewo eksportować")
+	output := exportCmd.String("o", "", "Plik wynikowy (domyślnie standardowe wyjście)")
+	exportCmd.Parse(args)
+
+	languages, err := parseLanguages(*langs)
+	if err != nil {
+		fmt.Println("Błąd:", err)
+		os.Exit(1)
+	}
+	_, tree, err := loadTree(*path)
+	if err != nil {
+		fmt.Println("Błąd:", err)
+		os.Exit(1)
+	}
 
-	// czytanie linia po linii
-	for i := 0; i < 5; i++ {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break // End of file
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Println("Błąd tworzenia pliku:", err)
+			os.Exit(1)
 		}
+		defer file.Close()
+		out = file
+	}
 
-		accidents_slice = append(accidents_slice, Nomenclature{record[0], record[1], record[2], record[3], record[4], record[5], record[6]})
+	if err := Export(out, tree, ExportFormat(*format), ExportOptions{Languages: languages, Subtree: *subtree}); err != nil {
+		fmt.Fprintln(os.Stderr, "Błąd eksportu:", err)
+		os.Exit(1)
 	}
+}
 
-	// sort.Slice(accidents_slice, func(i, j int) bool { return accidents_slice[i].de_label < accidents_slice[j].de_label })
-	// var labels []string
-	// for _, item := range accidents_slice {
-	// 	labels = append(labels, item.de_label)
-	// }
+func runDiff(args []string) {
+	diffCmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	oldPath := diffCmd.String("old", "", "Plik starej wersji słownika")
+	newPath := diffCmd.String("new", defaultNomenclaturePath, "Plik nowej wersji słownika")
+	diffCmd.Parse(args)
+	if *oldPath == "" {
+		fmt.Println("Użycie: program diff -old stary.csv [-new nowy.csv]")
+		os.Exit(1)
+	}
 
-	sort.Slice(accidents_slice, func(i, j int) bool { return accidents_slice[i].en_label < accidents_slice[j].en_label })
-	var labels []string
-	for _, item := range accidents_slice {
-		labels = append(labels, item.en_label)
+	oldItems, err := LoadNomenclature(*oldPath)
+	if err != nil {
+		fmt.Println("Błąd wczytywania starej wersji:", err)
+		os.Exit(1)
+	}
+	newItems, err := LoadNomenclature(*newPath)
+	if err != nil {
+		fmt.Println("Błąd wczytywania nowej wersji:", err)
+		os.Exit(1)
 	}
 
-	return labels
+	diff := DiffNomenclatures(oldItems, newItems)
+	if err := diff.WriteReport(os.Stdout); err != nil {
+		fmt.Println("Błąd zapisu raportu:", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Dodane: %d, usunięte: %d, zmienione: %d\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
 }
 
-func main() {
-	var new_data []string
+func runMigrate(args []string) {
+	migrateCmd := flag.NewFlagSet("migrate", flag.ExitOnError)
+	newPath := migrateCmd.String("new", defaultNomenclaturePath, "Plik nowej wersji słownika")
+	mapPath := migrateCmd.String("map", "", "Tabela przejścia stary kod;nowy kod (opcjonalna)")
+	mapDelimiter := migrateCmd.String("map-delimiter", "", "Separator pól w tabeli przejścia (domyślnie wykrywany: ';', ',' albo tabulator)")
+	column := migrateCmd.Int("column", 1, "Numer kolumny z kodem CPV (od 1)")
+	delimiter := migrateCmd.String("delimiter", ",", "Separator pól w migrowanym pliku")
+	header := migrateCmd.Bool("header", true, "Pierwszy wiersz jest nagłówkiem")
+	migrateCmd.Parse(args)
+	if migrateCmd.NArg() != 1 || len([]rune(*delimiter)) != 1 || len([]rune(*mapDelimiter)) > 1 {
+		fmt.Println("Użycie: program migrate [-new nowy.csv] [-map przejscie.csv] [-map-delimiter ;] [-column n] [-delimiter ,] dane.csv")
+		os.Exit(1)
+	}
+
+	newItems, err := LoadNomenclature(*newPath)
+	if err != nil {
+		fmt.Println("Błąd wczytywania słownika:", err)
+		os.Exit(1)
+	}
+	correspondence := Correspondence{}
+	if *mapPath != "" {
+		mapFile, err := os.Open(*mapPath)
+		if err != nil {
+			fmt.Println("Błąd otwierania tabeli przejścia:", err)
+			os.Exit(1)
+		}
+		var mapSeparator rune // 0 - wykrywany z pierwszej linii
+		if *mapDelimiter != "" {
+			mapSeparator = []rune(*mapDelimiter)[0]
+		}
+		correspondence, err = ReadCorrespondence(mapFile, mapSeparator)
+		mapFile.Close()
+		if err != nil {
+			fmt.Println("Błąd:", err)
+			os.Exit(1)
+		}
+	}
 
-	new_data = readCSVLineByLine("./nomenclature-cpv.csv")
+	data, err := os.Open(migrateCmd.Arg(0))
+	if err != nil {
+		fmt.Println("Błąd otwierania pliku:", err)
+		os.Exit(1)
+	}
+	defer data.Close()
 
-	fmt.Println(new_data)
+	opts := MigrateOptions{Column: *column, Separator: []rune(*delimiter)[0], Header: *header}
+	unmapped, err := MigrateCodes(data, os.Stdout, correspondence, IndexByShortCode(newItems), opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Błąd migracji:", err)
+		os.Exit(1)
+	}
+	for _, item := range unmapped {
+		fmt.Fprintf(os.Stderr, "linia %d: brak odpowiednika dla kodu %s\n", item.Line, item.Code)
+	}
+	if len(unmapped) > 0 {
+		os.Exit(2)
+	}
+}
+
+func runServe(args []string) {
+	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
+	path := serveCmd.String("file", defaultNomenclaturePath, "Plik słownika CPV")
+	addr := serveCmd.String("addr", ":8080", "Adres, na którym nasłuchuje serwer")
+	serveCmd.Parse(args)
+
+	_, tree, err := loadTree(*path)
+	if err != nil {
+		fmt.Println("Błąd:", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Serwer CPV (%d kodów) nasłuchuje na %s\n", tree.Len(), *addr)
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           NewCPVServer(tree).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Println("Błąd serwera:", err)
+		os.Exit(1)
+	}
+}
+
+func runClassify(args []string) {
+	classifyCmd := flag.NewFlagSet("classify", flag.ExitOnError)
+	path := classifyCmd.String("file", defaultNomenclaturePath, "Plik słownika CPV")
+	k := classifyCmd.Int("k", 5, "Liczba proponowanych kodów")
+	langs := classifyCmd.String("lang", "", "Języki etykiet oddzielone przecinkami (domyślnie wszystkie)")
+	evalPath := classifyCmd.String("eval", "", "Zbiór ewaluacyjny (opis<TAB>kody); wypisuje precision@k")
+	classifyCmd.Parse(args)
+	if *evalPath == "" && classifyCmd.NArg() == 0 {
+		fmt.Println("Użycie: program classify [-k 5] [-lang en] \"opis pozycji\" | program classify -eval zbior.tsv")
+		os.Exit(1)
+	}
+
+	languages, err := parseLanguages(*langs)
+	if err != nil {
+		fmt.Println("Błąd:", err)
+		os.Exit(1)
+	}
+	_, tree, err := loadTree(*path)
+	if err != nil {
+		fmt.Println("Błąd:", err)
+		os.Exit(1)
+	}
+	classifier := NewClassifier(NewSearchIndex(tree))
+
+	if *evalPath != "" {
+		file, err := os.Open(*evalPath)
+		if err != nil {
+			fmt.Println("Błąd otwierania pliku:", err)
+			os.Exit(1)
+		}
+		examples, err := ReadLabelledExamples(file)
+		file.Close()
+		if err != nil {
+			fmt.Println("Błąd:", err)
+			os.Exit(1)
+		}
+		eval, err := classifier.Evaluate(examples, *k)
+		if err != nil {
+			fmt.Println("Błąd:", err)
+			os.Exit(1)
+		}
+		for _, miss := range eval.Misses {
+			fmt.Printf("Brak trafienia: %q (oczekiwano %s)\n", miss.Description, strings.Join(miss.Codes, ", "))
+		}
+		fmt.Printf("Przykładów: %d, precision@%d: %.3f, trafienia@%d: %.3f\n", eval.Examples, eval.K, eval.PrecisionK, eval.K, eval.HitRateK)
+		return
+	}
+
+	candidates, err := classifier.Classify(strings.Join(classifyCmd.Args(), " "), *k, languages...)
+	if err != nil {
+		fmt.Println("Błąd:", err)
+		os.Exit(1)
+	}
+	for _, candidate := range candidates {
+		fmt.Printf("%s\t%.3f\t%s\n", candidate.Node.Code, candidate.Score, candidate.Node.Label(candidate.Language))
+	}
+}
+
+func printSummary(path string) {
+	nomenclatures, tree, err := loadTree(path)
+	if err != nil {
+		fmt.Println("Błąd:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Wczytano kodów CPV:", len(nomenclatures))
+
+	// kody niezgodne z short_code są błędem danych; inna cyfra kontrolna
+	// oznacza kod z historyczną cyfrą kontrolną z wcześniejszej wersji słownika
+	mismatched, legacyCheckDigits := 0, 0
+	for _, item := range nomenclatures {
+		code, err := item.ParseCode()
+		if err != nil {
+			fmt.Println("Błędny kod:", err)
+			mismatched++
+		} else if code.Verify() != nil {
+			legacyCheckDigits++
+		}
+	}
+	fmt.Println("Kody niezgodne z short_code:", mismatched, "kody z historyczną cyfrą kontrolną:", legacyCheckDigits)
+
+	ancestors, _ := tree.Ancestors("03111200")
+	var breadcrumbs []string
+	for _, node := range ancestors {
+		breadcrumbs = append(breadcrumbs, node.ENLabel)
+	}
+	fmt.Println("Działów:", len(tree.Roots), "ścieżka do 03111200:", strings.Join(breadcrumbs, " > "))
+
+	if err := SortNomenclatures(nomenclatures, SortKey{Column: "en"}, SortKey{Column: "code"}); err != nil {
+		fmt.Println("Błąd sortowania:", err)
+		os.Exit(1)
+	}
+	var labels []string
+	for _, item := range nomenclatures[:min(5, len(nomenclatures))] {
+		labels = append(labels, item.ENLabel)
+	}
 
+	fmt.Println(labels)
 }
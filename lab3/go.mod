@@ -0,0 +1,3 @@
+module lab3
+
+go 1.25.0
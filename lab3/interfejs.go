@@ -3,6 +3,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,6 +18,8 @@ type FileSystemItem interface {
 	Size() int64
 	CreatedAt() time.Time
 	ModifiedAt() time.Time
+	Mode() fs.FileMode
+	Owner() (uid, gid int)
 }
 
 // Interfejs definiujący obiekty które mogą być odczttywane
@@ -25,79 +32,167 @@ type Writable interface {
 	Write(p []byte) (n int, err error)
 }
 
-// Katalog definiuje pliki i podkatalogi
+// Katalog definiuje pliki i podkatalogi. Elementy dodaje się i usuwa tylko
+// przez VirtualFileSystem, który sprawdza prawa dostępu sesji.
 type Directory interface {
 	FileSystemItem
-	AddItem(item FileSystemItem) error
-	RemoveItem(name string) error
 	Items() []FileSystemItem
 }
 
+// fsError to błąd, który errors.Is rozpoznaje także jako odpowiadający mu
+// błąd z pakietu io/fs (np. ErrItemNotFound jako fs.ErrNotExist)
+type fsError struct {
+	msg   string
+	fsErr error
+}
+
+func newFSError(msg string, fsErr error) error { return &fsError{msg: msg, fsErr: fsErr} }
+
+func (e *fsError) Error() string        { return e.msg }
+func (e *fsError) Is(target error) bool { return target == e.fsErr }
+
 // Przykładowe komunikaty błędów, które można użyć
 var (
-	ErrItemExists       = errors.New("item already exists")
-	ErrItemNotFound     = errors.New("item not found")
+	ErrItemExists       = newFSError("item already exists", fs.ErrExist)
+	ErrItemNotFound     = newFSError("item not found", fs.ErrNotExist)
 	ErrNotImplemented   = errors.New("operation not implemented")
-	ErrPermissionDenied = errors.New("permission denied")
+	ErrPermissionDenied = newFSError("permission denied", fs.ErrPermission)
 	ErrNotDirectory     = errors.New("not a directory")
 	ErrIsDirectory      = errors.New("is a directory")
+	ErrNotSymlink       = errors.New("not a symbolic link")
+	ErrSymlinkLoop      = errors.New("too many levels of symbolic links")
+	ErrInvalidName      = newFSError("invalid name", fs.ErrInvalid)
+	ErrInvalidMove      = errors.New("cannot move a directory into itself")
+	ErrInvalidOffset    = newFSError("invalid offset", fs.ErrInvalid)
+	ErrAppendWriteAt    = errors.New("invalid use of WriteAt on file opened with O_APPEND")
+	ErrFileTooLarge     = newFSError("file too large", fs.ErrInvalid)
 )
 
-type Plik struct {
-	name       string
-	path       string
-	size       int64
-	createdAt  time.Time
-	modifiedAt time.Time
+// Maksymalna liczba dowiązań symbolicznych rozwijanych podczas jednego
+// wyszukiwania; większa liczba oznacza pętlę dowiązań
+const MaxSymlinkDepth = 40
+
+// Maksymalny rozmiar pliku w bajtach; zapis, który sięgałby dalej, zwraca
+// ErrFileTooLarge zamiast rezerwować pamięć na lukę przed danymi
+const MaxFileSize = 1 << 30
+
+// entry to nazwa i ścieżka elementu oraz drzewo, do którego element należy.
+// Nazwy i ścieżki zmieniają się tylko przy przenoszeniu, więc chroni je
+// jedna blokada namesMu drzewa. Elementu spoza drzewa nikt nie przenosi,
+// więc jego pola są czytane bez blokady.
+type entry struct {
+	tree *fileTree
+	name string
+	path string
+}
+
+func (e *entry) Name() string {
+	if e.tree != nil {
+		e.tree.namesMu.RLock()
+		defer e.tree.namesMu.RUnlock()
+	}
+	return e.name
+}
+func (e *entry) Path() string {
+	if e.tree != nil {
+		e.tree.namesMu.RLock()
+		defer e.tree.namesMu.RUnlock()
+	}
+	return e.path
+}
+
+// Dane pliku współdzielone przez wszystkie jego dowiązania twarde
+type fileData struct {
+	mu         sync.RWMutex
 	data       []byte
+	modifiedAt time.Time
+	links      int
+	attributes
 }
 
-func (f *Plik) Name() string          { return f.name }
-func (f *Plik) Path() string          { return f.path }
-func (f *Plik) Size() int64           { return int64(len(f.data)) }
-func (f *Plik) CreatedAt() time.Time  { return f.createdAt }
-func (f *Plik) ModifiedAt() time.Time { return f.modifiedAt }
-func (f *Plik) Read(p []byte) (int, error) {
-	copy(p, f.data)
-	return len(f.data), nil
+type Plik struct {
+	entry
+	createdAt time.Time
+	content   *fileData
 }
-func (f *Plik) Write(p []byte) (int, error) {
-	f.data = append(f.data, p...)
-	f.modifiedAt = time.Now()
-	return len(p), nil
+
+func (t *fileTree) newPlik(name, path string, data []byte, mode fs.FileMode, owner Credentials) *Plik {
+	now := time.Now()
+	content := &fileData{data: data, modifiedAt: now, links: 1, attributes: newAttributes(mode, owner)}
+	return &Plik{entry: entry{tree: t, name: name, path: path}, createdAt: now, content: content}
 }
 
+func (f *Plik) Size() int64 {
+	f.content.mu.RLock()
+	defer f.content.mu.RUnlock()
+	return int64(len(f.content.data))
+}
+func (f *Plik) CreatedAt() time.Time { return f.createdAt }
+func (f *Plik) ModifiedAt() time.Time {
+	f.content.mu.RLock()
+	defer f.content.mu.RUnlock()
+	return f.content.modifiedAt
+}
+
+// Links zwraca liczbę dowiązań twardych wskazujących na dane pliku
+func (f *Plik) Links() int {
+	f.content.mu.RLock()
+	defer f.content.mu.RUnlock()
+	return f.content.links
+}
+
+// Katalog ma własną blokadę chroniącą mapę items, więc operacje w różnych
+// katalogach nie czekają na siebie
 type Katalog struct {
-	name       string
-	path       string
+	mu sync.RWMutex
+	entry
 	size       int64
 	createdAt  time.Time
 	modifiedAt time.Time
 	items      map[string]FileSystemItem
+	attributes
 }
 
-func (d *Katalog) Name() string          { return d.name }
-func (d *Katalog) Path() string          { return d.path }
-func (d *Katalog) Size() int64           { return d.size }
-func (d *Katalog) CreatedAt() time.Time  { return d.createdAt }
-func (d *Katalog) ModifiedAt() time.Time { return d.modifiedAt }
-func (d *Katalog) AddItem(item FileSystemItem) error {
-	if _, exists := d.items[item.Name()]; exists {
+func (t *fileTree) newKatalog(name, path string, mode fs.FileMode, owner Credentials) *Katalog {
+	now := time.Now()
+	return &Katalog{entry: entry{tree: t, name: name, path: path}, createdAt: now, modifiedAt: now, items: make(map[string]FileSystemItem), attributes: newAttributes(mode, owner)}
+}
+
+func (d *Katalog) Size() int64          { return d.size }
+func (d *Katalog) CreatedAt() time.Time { return d.createdAt }
+func (d *Katalog) ModifiedAt() time.Time {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.modifiedAt
+}
+
+// addItem dodaje element do katalogu bez sprawdzania praw dostępu;
+// wywołujący sprawdza je wcześniej i trzyma vfs.mu
+func (d *Katalog) addItem(item FileSystemItem) error {
+	// nazwę odczytujemy przed blokadą katalogu, zob. fileTree
+	name := item.Name()
+	if !validName(name) {
+		return ErrInvalidName
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exists := d.items[name]; exists {
 		return ErrItemExists
 	}
-	d.items[item.Name()] = item
+	d.items[name] = item
 	d.modifiedAt = time.Now()
 	return nil
 }
-func (d *Katalog) RemoveItem(name string) error {
-	if _, exists := d.items[name]; !exists {
-		return ErrItemNotFound
-	}
-	delete(d.items, name)
-	d.modifiedAt = time.Now()
-	return nil
+
+// removeItem usuwa element z katalogu bez zwalniania go (zob. release)
+func (d *Katalog) removeItem(name string) error {
+	_, err := d.take(name, nil)
+	return err
 }
+
 func (d *Katalog) Items() []FileSystemItem {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	var result []FileSystemItem
 	for _, item := range d.items {
 		result = append(result, item)
@@ -105,72 +200,167 @@ func (d *Katalog) Items() []FileSystemItem {
 	return result
 }
 
+// lookup zwraca element katalogu o podanej nazwie
+func (d *Katalog) lookup(name string) (FileSystemItem, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	item, ok := d.items[name]
+	return item, ok
+}
+
+// take usuwa element z katalogu i zwraca go w jednym kroku, więc dwa
+// równoległe usunięcia tego samego elementu nie zwolnią go dwukrotnie.
+// Jeśli allow nie jest nil, element jest usuwany tylko wtedy, gdy allow
+// nie zwróci błędu.
+func (d *Katalog) take(name string, allow func(FileSystemItem) error) (FileSystemItem, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	item, exists := d.items[name]
+	if !exists {
+		return nil, ErrItemNotFound
+	}
+	if allow != nil {
+		if err := allow(item); err != nil {
+			return nil, err
+		}
+	}
+	delete(d.items, name)
+	d.modifiedAt = time.Now()
+	return item, nil
+}
+
+// snapshot zwraca kopię mapy items do przeglądania bez trzymania blokady
+func (d *Katalog) snapshot() map[string]FileSystemItem {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	items := make(map[string]FileSystemItem, len(d.items))
+	for name, item := range d.items {
+		items[name] = item
+	}
+	return items
+}
+
+// Dowiązanie symboliczne przechowuje ścieżkę celu (bezwzględną albo względną
+// wobec katalogu dowiązania), rozwijaną dopiero podczas wyszukiwania
 type SymLink struct {
-	name       string
-	path       string
-	size       int64
+	entry
 	createdAt  time.Time
 	modifiedAt time.Time
-	target     FileSystemItem
+	target     string
+	attributes
 }
 
-func (s *SymLink) Name() string          { return s.name }
-func (s *SymLink) Path() string          { return s.path }
-func (s *SymLink) Size() int64           { return s.size }
+// Rozmiar dowiązania to długość ścieżki celu, tak jak w systemach Unix
+func (s *SymLink) Size() int64           { return int64(len(s.target)) }
 func (s *SymLink) CreatedAt() time.Time  { return s.createdAt }
 func (s *SymLink) ModifiedAt() time.Time { return s.modifiedAt }
 
 type ReadOnlyFile struct {
-	name       string
-	path       string
+	entry
 	size       int64
 	createdAt  time.Time
 	modifiedAt time.Time
 	data       []byte
+	attributes
 }
 
-func (r *ReadOnlyFile) Name() string          { return r.name }
-func (r *ReadOnlyFile) Path() string          { return r.path }
 func (r *ReadOnlyFile) Size() int64           { return int64(len(r.data)) }
 func (r *ReadOnlyFile) CreatedAt() time.Time  { return r.createdAt }
 func (r *ReadOnlyFile) ModifiedAt() time.Time { return r.modifiedAt }
-func (r *ReadOnlyFile) Read(p []byte) (int, error) {
-	copy(p, r.data)
-	return len(r.data), nil
-}
 
+// VirtualFileSystem można używać z wielu goroutine. Operacje dotyczące jednego
+// katalogu (tworzenie, usuwanie, wyszukiwanie, otwieranie) biorą blokadę mu
+// do odczytu i blokują tylko katalogi, do których zaglądają, po jednym naraz.
+// Move, Copy i Rename zmieniają kilka katalogów, więc biorą mu na wyłączność:
+// są atomowe względem pozostałych operacji, a jedna blokada wyklucza
+// zakleszczenia. Dane plików mają osobne blokady, więc czytanie i zapis
+// otwartych plików nie blokuje drzewa.
+//
+// Każda operacja jest sprawdzana względem praw dostępu użytkownika sesji
+// (zob. WithCredentials); nowe elementy należą do tego użytkownika.
 type VirtualFileSystem struct {
-	root *Katalog
+	*fileTree
+	cred Credentials
 }
 
+// fileTree to drzewo współdzielone przez wszystkie sesje jednego systemu
+// plików. Blokady namesMu i attrsMu chronią nazwy i ścieżki oraz prawa
+// dostępu i właścicieli elementów drzewa; każde drzewo ma własne, więc
+// operacje na jednym nie blokują innych. namesMu bierze się przed blokadą
+// katalogu, nigdy w trakcie jej trzymania, a trzymając attrsMu, nie bierze
+// się żadnej innej blokady.
+type fileTree struct {
+	mu      sync.RWMutex
+	namesMu sync.RWMutex
+	attrsMu sync.RWMutex
+	root    *Katalog
+}
+
+// NewVirtualFileSystem tworzy pusty system plików z sesją użytkownika root
 func NewVirtualFileSystem() *VirtualFileSystem {
-	return &VirtualFileSystem{
-		root: &Katalog{name: "root", path: "/", items: make(map[string]FileSystemItem)},
-	}
+	tree := &fileTree{}
+	tree.root = tree.newKatalog("root", "/", DefaultDirMode, Root)
+	return &VirtualFileSystem{fileTree: tree, cred: Root}
 }
 
 func (vfs *VirtualFileSystem) CreateFile(path, name string, data []byte) error {
-	folder, err := vfs.FindFolder(path)
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	folder, err := vfs.findWritableFolder(path)
+	if err != nil {
+		return err
+	}
+	return folder.addItem(vfs.newPlik(name, folder.Path()+name, data, DefaultFileMode, vfs.cred))
+}
+
+// CreateReadOnlyFile tworzy plik, którego zawartości nie zmieni żaden uchwyt,
+// nawet otwarty przez roota
+func (vfs *VirtualFileSystem) CreateReadOnlyFile(path, name string, data []byte) error {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	folder, err := vfs.findWritableFolder(path)
 	if err != nil {
 		return err
 	}
-	return folder.AddItem(&Plik{name: name, path: path + name, data: data, createdAt: time.Now(), modifiedAt: time.Now()})
+	now := time.Now()
+	return folder.addItem(&ReadOnlyFile{entry: entry{tree: vfs.fileTree, name: name, path: folder.Path() + name}, createdAt: now, modifiedAt: now, data: data, attributes: newAttributes(DefaultFileMode, vfs.cred)})
 }
 
 func (vfs *VirtualFileSystem) CreateFolder(path, name string) error {
-	folder, err := vfs.FindFolder(path)
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	folder, err := vfs.findWritableFolder(path)
 	if err != nil {
 		return err
 	}
-	return folder.AddItem(&Katalog{name: name, path: path + name + "/", items: make(map[string]FileSystemItem), createdAt: time.Now(), modifiedAt: time.Now()})
+	return folder.addItem(vfs.newKatalog(name, folder.Path()+name+"/", DefaultDirMode, vfs.cred))
 }
 
+// FindItem wyszukuje element, rozwijając po drodze dowiązania symboliczne,
+// także na końcu ścieżki
 func (vfs *VirtualFileSystem) FindItem(path string) (FileSystemItem, error) {
-	return vfs.findItemRecursive(vfs.root, path)
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	return vfs.resolve(path, true)
+}
+
+// FindItemNoFollow działa jak FindItem, ale dowiązanie na końcu ścieżki
+// zwraca bez rozwijania
+func (vfs *VirtualFileSystem) FindItemNoFollow(path string) (FileSystemItem, error) {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	return vfs.resolve(path, false)
 }
 
 func (vfs *VirtualFileSystem) FindFolder(path string) (*Katalog, error) {
-	item, err := vfs.FindItem(path)
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	return vfs.findFolder(path)
+}
+
+// findFolder to FindFolder dla wywołującego, który trzyma już vfs.mu
+func (vfs *VirtualFileSystem) findFolder(path string) (*Katalog, error) {
+	item, err := vfs.resolve(path, true)
 	if err != nil {
 		return nil, err
 	}
@@ -181,77 +371,739 @@ func (vfs *VirtualFileSystem) FindFolder(path string) (*Katalog, error) {
 	return folder, nil
 }
 
-func (vfs *VirtualFileSystem) findItemRecursive(folder *Katalog, path string) (FileSystemItem, error) {
-	if folder.Path() == path {
-		return folder, nil
+// findWritableFolder zwraca katalog, w którym użytkownik sesji może tworzyć
+// i usuwać elementy (ma do niego prawa zapisu i przeszukiwania)
+func (vfs *VirtualFileSystem) findWritableFolder(path string) (*Katalog, error) {
+	folder, err := vfs.findFolder(path)
+	if err != nil {
+		return nil, err
 	}
-	for _, item := range folder.items {
-		if item.Path() == path {
-			return item, nil
+	if err := vfs.cred.check(folder, permWrite|permExec); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// validName sprawdza, czy nazwa jest jednym elementem ścieżki
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.Contains(name, "/")
+}
+
+func splitComponents(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
+// resolve przechodzi ścieżkę element po elemencie od korzenia, zaglądając
+// tylko do map items kolejnych katalogów, więc koszt zależy od głębokości
+// ścieżki, a nie od liczby elementów systemu plików. Ścieżki względne są
+// liczone od korzenia. Ukośnik na końcu działa jak "/.", więc "/docs"
+// i "/docs/" wskazują ten sam katalog, a "/plik.txt/" zwraca ErrNotDirectory.
+// Dowiązanie symboliczne jest zastępowane elementami swojego celu: cel
+// bezwzględny zaczyna przechodzenie od korzenia, a względny od katalogu
+// dowiązania. Stos odwiedzonych katalogów pozwala obsłużyć "..", który tak
+// jak w systemach Unix prowadzi do rodzica katalogu, do którego faktycznie
+// doszliśmy, także przez dowiązanie. Zajrzenie do katalogu wymaga prawa
+// przeszukiwania (x). Wywołujący trzyma vfs.mu.
+func (vfs *VirtualFileSystem) resolve(path string, followLast bool) (FileSystemItem, error) {
+	stack := []*Katalog{vfs.root}
+	components := splitComponents(path)
+	if len(components) > 0 && strings.HasSuffix(path, "/") {
+		components = append(components, ".")
+	}
+	depth := 0
+	for len(components) > 0 {
+		name := components[0]
+		components = components[1:]
+		switch name {
+		case ".":
+			continue
+		case "..":
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		dir := stack[len(stack)-1]
+		if err := vfs.cred.check(dir, permExec); err != nil {
+			return nil, err
+		}
+		item, ok := dir.lookup(name)
+		if !ok {
+			return nil, ErrItemNotFound
 		}
-		if subFolder, ok := item.(*Katalog); ok {
-			if found, err := vfs.findItemRecursive(subFolder, path); err == nil {
-				return found, nil
+		if link, ok := item.(*SymLink); ok && (len(components) > 0 || followLast) {
+			// pusty cel nie wskazuje żadnego elementu, tak jak w systemach Unix
+			if link.target == "" {
+				return nil, ErrItemNotFound
 			}
+			depth++
+			if depth > MaxSymlinkDepth {
+				return nil, ErrSymlinkLoop
+			}
+			if strings.HasPrefix(link.target, "/") {
+				stack = stack[:1]
+			}
+			components = append(splitComponents(link.target), components...)
+			continue
+		}
+		if len(components) == 0 {
+			return item, nil
 		}
+		folder, ok := item.(*Katalog)
+		if !ok {
+			return nil, ErrNotDirectory
+		}
+		stack = append(stack, folder)
 	}
-	return nil, ErrItemNotFound
+	return stack[len(stack)-1], nil
 }
 
+// DeleteItem usuwa element wskazany ścieżką; dowiązanie symboliczne jest
+// usuwane samo, bez celu. Dane pliku są zwalniane dopiero po usunięciu
+// ostatniego dowiązania twardego. Usunięcie katalogu wymaga prawa usunięcia
+// każdego elementu w nim zawartego.
 func (vfs *VirtualFileSystem) DeleteItem(path string) error {
-	parentPath, name := splitPath(path)
-	folder, err := vfs.FindFolder(parentPath)
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	folder, name, err := vfs.locate(path)
+	if err != nil {
+		return err
+	}
+	item, err := folder.take(name, func(item FileSystemItem) error {
+		if err := vfs.cred.canRemove(folder, item); err != nil {
+			return err
+		}
+		return vfs.cred.canRemoveTree(item)
+	})
 	if err != nil {
 		return err
 	}
-	return folder.RemoveItem(name)
+	release(item)
+	return nil
+}
+
+// release zmniejsza liczniki dowiązań plików w usuniętym elemencie. Dane
+// nie są zwalniane, bo otwarte uchwyty nadal z nich korzystają, tak jak po
+// unlink w systemach uniksowych; pamięć odzyska GC po zamknięciu ostatniego.
+func release(item FileSystemItem) {
+	switch it := item.(type) {
+	case *Plik:
+		it.content.mu.Lock()
+		it.content.links--
+		it.content.mu.Unlock()
+	case *Katalog:
+		for _, child := range it.snapshot() {
+			release(child)
+		}
+	}
 }
 
-func splitPath(path string) (string, string) {
-	if path == "/" {
-		return "/", ""
+// CreateSymlink tworzy dowiązanie symboliczne do ścieżki target. Cel nie musi
+// istnieć; odwołanie przez dowiązanie do nieistniejącego celu zwraca ErrItemNotFound.
+func (vfs *VirtualFileSystem) CreateSymlink(path, name, target string) error {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	folder, err := vfs.findWritableFolder(path)
+	if err != nil {
+		return err
 	}
-	lastSlash := len(path) - 1
-	for lastSlash >= 0 && path[lastSlash] != '/' {
-		lastSlash--
+	now := time.Now()
+	return folder.addItem(&SymLink{entry: entry{tree: vfs.fileTree, name: name, path: folder.Path() + name}, createdAt: now, modifiedAt: now, target: target, attributes: newAttributes(DefaultSymlinkMode, vfs.cred)})
+}
+
+// Readlink zwraca ścieżkę celu dowiązania symbolicznego
+func (vfs *VirtualFileSystem) Readlink(path string) (string, error) {
+	item, err := vfs.FindItemNoFollow(path)
+	if err != nil {
+		return "", err
 	}
-	if lastSlash == 0 {
-		return "/", path[1:]
+	link, ok := item.(*SymLink)
+	if !ok {
+		return "", ErrNotSymlink
 	}
-	return path[:lastSlash+1], path[lastSlash+1:]
+	return link.target, nil
 }
 
-func (vfs *VirtualFileSystem) CreateSymlink(path, name, pathOriginal string) error {
-	folder, err := vfs.FindFolder(path)
+// CreateHardLink tworzy w katalogu path nową nazwę dla istniejącego pliku;
+// obie nazwy współdzielą dane, a dowiązań twardych do katalogów nie można tworzyć
+func (vfs *VirtualFileSystem) CreateHardLink(path, name, pathOriginal string) error {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	folder, err := vfs.findWritableFolder(path)
 	if err != nil {
 		return err
 	}
-	original, err := vfs.FindItem(pathOriginal)
+	original, err := vfs.resolve(pathOriginal, true)
 	if err != nil {
 		return err
 	}
-	return folder.AddItem(&SymLink{name: name, path: path + name, createdAt: time.Now(), modifiedAt: time.Now(), target: original})
+	file, ok := original.(*Plik)
+	if !ok {
+		if _, isDir := original.(*Katalog); isDir {
+			return ErrIsDirectory
+		}
+		return ErrNotImplemented
+	}
+	// licznik rośnie przed dodaniem nazwy, żeby równoległe usunięcie nowego
+	// dowiązania nie zwolniło danych
+	file.content.mu.Lock()
+	file.content.links++
+	file.content.mu.Unlock()
+	link := &Plik{entry: entry{tree: vfs.fileTree, name: name, path: folder.Path() + name}, createdAt: time.Now(), content: file.content}
+	if err := folder.addItem(link); err != nil {
+		release(link)
+		return err
+	}
+	return nil
+}
+
+// locate zwraca katalog nadrzędny i nazwę elementu wskazanego ścieżką bez
+// rozwijania dowiązania na jej końcu. Katalog nadrzędny jest wyszukiwany przez
+// resolve, więc każda operacja rozumie ścieżkę tak samo jak FindItem. Korzenia
+// nie można usuwać, przenosić ani kopiować, ścieżka nie może kończyć się na
+// "." ani "..", a ukośnik na końcu wymaga, żeby istniejący element był katalogiem.
+func (vfs *VirtualFileSystem) locate(path string) (*Katalog, string, error) {
+	components := splitComponents(path)
+	if len(components) == 0 {
+		return nil, "", ErrPermissionDenied
+	}
+	name := components[len(components)-1]
+	if name == "." || name == ".." {
+		return nil, "", ErrInvalidName
+	}
+	folder, err := vfs.findFolder(strings.Join(components[:len(components)-1], "/"))
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.HasSuffix(path, "/") {
+		if item, exists := folder.lookup(name); exists {
+			if _, isDir := item.(*Katalog); !isDir {
+				return nil, "", ErrNotDirectory
+			}
+		}
+	}
+	return folder, name, nil
+}
+
+// prepareDestination sprawdza, czy element można umieścić pod nazwą name
+// w katalogu folder. Istniejący element może zostać zastąpiony tylko przy
+// overwrite i tylko elementem tego samego rodzaju (katalog - pustym katalogiem),
+// o ile użytkownik sesji może go usunąć.
+func (vfs *VirtualFileSystem) prepareDestination(folder *Katalog, name string, item FileSystemItem, overwrite bool) (FileSystemItem, error) {
+	if !validName(name) {
+		return nil, ErrInvalidName
+	}
+	if err := vfs.cred.check(folder, permWrite|permExec); err != nil {
+		return nil, err
+	}
+	if dir, ok := item.(*Katalog); ok && (folder == dir || strings.HasPrefix(folder.Path(), dir.Path())) {
+		return nil, ErrInvalidMove
+	}
+	existing, exists := folder.lookup(name)
+	if !exists {
+		return nil, nil
+	}
+	if !overwrite {
+		return nil, ErrItemExists
+	}
+	existingDir, existingIsDir := existing.(*Katalog)
+	_, itemIsDir := item.(*Katalog)
+	switch {
+	case existingIsDir && !itemIsDir:
+		return nil, ErrIsDirectory
+	case !existingIsDir && itemIsDir:
+		return nil, ErrNotDirectory
+	case existingIsDir && len(existingDir.snapshot()) > 0:
+		return nil, ErrItemExists
+	}
+	if err := vfs.cred.canRemove(folder, existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// setPath nadaje elementowi nową nazwę i ścieżkę oraz poprawia ścieżki
+// wszystkich jego potomków
+func (t *fileTree) setPath(item FileSystemItem, name, path string) {
+	t.namesMu.Lock()
+	defer t.namesMu.Unlock()
+	setPathLocked(item, name, path)
+}
+
+func setPathLocked(item FileSystemItem, name, path string) {
+	switch it := item.(type) {
+	case *Plik:
+		it.name, it.path = name, path
+	case *ReadOnlyFile:
+		it.name, it.path = name, path
+	case *SymLink:
+		it.name, it.path = name, path
+	case *Katalog:
+		it.name, it.path = name, path+"/"
+		for childName, child := range it.snapshot() {
+			setPathLocked(child, childName, it.path+childName)
+		}
+	}
+}
+
+// Move przenosi element src pod ścieżkę dst (razem z nową nazwą). Dowiązanie
+// symboliczne jest przenoszone samo, bez celu.
+func (vfs *VirtualFileSystem) Move(src, dst string, overwrite bool) error {
+	vfs.mu.Lock()
+	defer vfs.mu.Unlock()
+	srcFolder, srcName, err := vfs.locate(src)
+	if err != nil {
+		return err
+	}
+	dstFolder, dstName, err := vfs.locate(dst)
+	if err != nil {
+		return err
+	}
+	return vfs.move(srcFolder, srcName, dstFolder, dstName, overwrite)
+}
+
+// move przenosi element srcName z katalogu srcFolder do katalogu dstFolder
+// pod nazwę dstName; wywołujący trzyma vfs.mu na wyłączność
+func (vfs *VirtualFileSystem) move(srcFolder *Katalog, srcName string, dstFolder *Katalog, dstName string, overwrite bool) error {
+	item, exists := srcFolder.lookup(srcName)
+	if !exists {
+		return ErrItemNotFound
+	}
+	if err := vfs.cred.canRemove(srcFolder, item); err != nil {
+		return err
+	}
+	if srcFolder == dstFolder && srcName == dstName {
+		return nil
+	}
+	existing, err := vfs.prepareDestination(dstFolder, dstName, item, overwrite)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		dstFolder.removeItem(dstName)
+		release(existing)
+	}
+	srcFolder.removeItem(srcName)
+	vfs.setPath(item, dstName, dstFolder.Path()+dstName)
+	return dstFolder.addItem(item)
+}
+
+// Rename zmienia nazwę elementu bez przenoszenia go do innego katalogu
+func (vfs *VirtualFileSystem) Rename(path, newName string, overwrite bool) error {
+	if !validName(newName) {
+		return ErrInvalidName
+	}
+	vfs.mu.Lock()
+	defer vfs.mu.Unlock()
+	folder, name, err := vfs.locate(path)
+	if err != nil {
+		return err
+	}
+	return vfs.move(folder, name, folder, newName, overwrite)
+}
+
+// Copy kopiuje element src pod ścieżkę dst. Katalogi są kopiowane tylko przy
+// recursive, dowiązania symboliczne są kopiowane jako dowiązania, a kopia
+// pliku ma własne dane, nawet jeśli oryginał ma kilka dowiązań twardych.
+func (vfs *VirtualFileSystem) Copy(src, dst string, recursive, overwrite bool) error {
+	vfs.mu.Lock()
+	defer vfs.mu.Unlock()
+	srcFolder, srcName, err := vfs.locate(src)
+	if err != nil {
+		return err
+	}
+	item, exists := srcFolder.lookup(srcName)
+	if !exists {
+		return ErrItemNotFound
+	}
+	if _, isDir := item.(*Katalog); isDir && !recursive {
+		return ErrIsDirectory
+	}
+	if err := vfs.cred.canReadTree(item); err != nil {
+		return err
+	}
+	dstFolder, dstName, err := vfs.locate(dst)
+	if err != nil {
+		return err
+	}
+	if srcFolder == dstFolder && srcName == dstName {
+		return ErrItemExists
+	}
+	existing, err := vfs.prepareDestination(dstFolder, dstName, item, overwrite)
+	if err != nil {
+		return err
+	}
+
+	clone := vfs.cloneItem(item, vfs.cred)
+	if existing != nil {
+		dstFolder.removeItem(dstName)
+		release(existing)
+	}
+	vfs.setPath(clone, dstName, dstFolder.Path()+dstName)
+	return dstFolder.addItem(clone)
+}
+
+// cloneItem tworzy w drzewie t głęboką kopię elementu należącą do owner,
+// z prawami dostępu oryginału; ścieżki ustawia później setPath
+func (t *fileTree) cloneItem(item FileSystemItem, owner Credentials) FileSystemItem {
+	now := time.Now()
+	switch it := item.(type) {
+	case *Plik:
+		it.content.mu.RLock()
+		defer it.content.mu.RUnlock()
+		return t.newPlik(it.name, it.path, append([]byte(nil), it.content.data...), perm(it.attrs()), owner)
+	case *ReadOnlyFile:
+		return &ReadOnlyFile{entry: entry{tree: t, name: it.name, path: it.path}, createdAt: now, modifiedAt: now, data: append([]byte(nil), it.data...), attributes: newAttributes(perm(it.attrs()), owner)}
+	case *SymLink:
+		return &SymLink{entry: entry{tree: t, name: it.name, path: it.path}, createdAt: now, modifiedAt: now, target: it.target, attributes: newAttributes(perm(it.attrs()), owner)}
+	case *Katalog:
+		clone := t.newKatalog(it.name, it.path, perm(it.attrs()), owner)
+		for name, child := range it.snapshot() {
+			clone.items[name] = t.cloneItem(child, owner)
+		}
+		return clone
+	}
+	return item
+}
+
+// FileHandle to otwarty plik z własną pozycją odczytu i zapisu. Implementuje
+// io.Reader, io.Writer, io.Seeker, io.ReaderAt, io.WriterAt i io.Closer.
+type FileHandle struct {
+	mu       sync.Mutex // chroni offset i closed
+	item     FileSystemItem
+	name     string // nazwa, pod którą plik otwarto przez io/fs
+	content  *fileData
+	flag     int
+	offset   int64
+	closed   bool
+	readOnly bool // plik typu ReadOnlyFile
+}
+
+// OpenFile otwiera plik z flagami os.O_RDONLY, os.O_WRONLY lub os.O_RDWR,
+// uzupełnionymi o os.O_APPEND, os.O_TRUNC, os.O_CREATE i os.O_EXCL.
+// Dowiązania symboliczne są rozwijane, a os.O_CREATE na wiszącym dowiązaniu
+// tworzy plik pod jego celem. Odczyt wymaga prawa r, a zapis i obcięcie
+// prawa w do pliku.
+func (vfs *VirtualFileSystem) OpenFile(path string, flag int) (*FileHandle, error) {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	item, err := vfs.resolve(path, true)
+	switch {
+	case errors.Is(err, ErrItemNotFound) && flag&os.O_CREATE != 0:
+		if strings.HasSuffix(path, "/") {
+			// ścieżka z ukośnikiem na końcu wskazuje katalog, a nie nowy plik
+			return nil, ErrIsDirectory
+		}
+		folder, name, err := vfs.locate(path)
+		if err != nil {
+			return nil, err
+		}
+		// wiszące dowiązanie wskazuje nazwę, pod którą powstaje nowy plik
+		for depth := 0; ; depth++ {
+			existing, _ := folder.lookup(name)
+			link, isLink := existing.(*SymLink)
+			if !isLink {
+				break
+			}
+			switch {
+			case flag&os.O_EXCL != 0:
+				return nil, ErrItemExists
+			case link.target == "":
+				return nil, ErrItemNotFound
+			case depth >= MaxSymlinkDepth:
+				return nil, ErrSymlinkLoop
+			}
+			target := link.target
+			if !strings.HasPrefix(target, "/") {
+				target = folder.Path() + target
+			}
+			if strings.HasSuffix(target, "/") {
+				return nil, ErrIsDirectory
+			}
+			if folder, name, err = vfs.locate(target); err != nil {
+				return nil, err
+			}
+		}
+		if err := vfs.cred.check(folder, permWrite|permExec); err != nil {
+			return nil, err
+		}
+		file := vfs.newPlik(name, folder.Path()+name, nil, DefaultFileMode, vfs.cred)
+		err = folder.addItem(file)
+		if errors.Is(err, ErrItemExists) && flag&os.O_EXCL == 0 {
+			// plik utworzyła w międzyczasie inna goroutine
+			existing, _ := folder.lookup(name)
+			item = existing
+		} else if err != nil {
+			return nil, err
+		} else {
+			item = file
+		}
+	case err != nil:
+		return nil, err
+	case flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, ErrItemExists
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if _, isDir := item.(*Katalog); !isDir {
+		if flag&os.O_WRONLY == 0 && !vfs.cred.can(item, permRead) {
+			return nil, ErrPermissionDenied
+		}
+		if (writable || flag&os.O_TRUNC != 0) && !vfs.cred.can(item, permWrite) {
+			return nil, ErrPermissionDenied
+		}
+	}
+	handle := &FileHandle{item: item, flag: flag}
+	switch it := item.(type) {
+	case *Plik:
+		handle.content = it.content
+	case *ReadOnlyFile:
+		if writable || flag&os.O_TRUNC != 0 {
+			return nil, ErrPermissionDenied
+		}
+		handle.content = &fileData{data: it.data, modifiedAt: it.modifiedAt}
+		handle.readOnly = true
+	case *Katalog:
+		return nil, ErrIsDirectory
+	default:
+		return nil, ErrNotImplemented
+	}
+
+	if writable && flag&os.O_TRUNC != 0 {
+		handle.content.mu.Lock()
+		handle.content.data = nil
+		handle.content.modifiedAt = time.Now()
+		handle.content.mu.Unlock()
+	}
+	return handle, nil
+}
+
+// Name zwraca ścieżkę otwartego pliku
+func (h *FileHandle) Name() string { return h.item.Path() }
+
+func (h *FileHandle) canRead() error {
+	if h.closed {
+		return os.ErrClosed
+	}
+	if h.flag&os.O_WRONLY != 0 {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func (h *FileHandle) canWrite() error {
+	if h.closed {
+		return os.ErrClosed
+	}
+	if h.readOnly || h.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func (h *FileHandle) Read(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.canRead(); err != nil {
+		return 0, err
+	}
+	n, err := h.content.readAt(p, h.offset)
+	h.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		// io.Reader może zgłosić koniec pliku dopiero przy kolejnym wywołaniu
+		err = nil
+	}
+	return n, err
+}
+
+func (h *FileHandle) ReadAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	err := h.canRead()
+	h.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return h.content.readAt(p, off)
+}
+
+func (h *FileHandle) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.canWrite(); err != nil {
+		return 0, err
+	}
+	n, end, err := h.content.writeAt(p, h.offset, h.flag&os.O_APPEND != 0)
+	h.offset = end
+	return n, err
+}
+
+func (h *FileHandle) WriteAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	err := h.canWrite()
+	h.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if h.flag&os.O_APPEND != 0 {
+		return 0, ErrAppendWriteAt
+	}
+	n, _, err := h.content.writeAt(p, off, false)
+	return n, err
+}
+
+// readAt czyta dane pliku od pozycji off
+func (c *fileData) readAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if off >= int64(len(c.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, c.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// writeAt zapisuje p od pozycji off albo, przy appendMode, na końcu pliku
+// i zwraca pozycję za zapisanymi danymi; zapis za końcem pliku wypełnia lukę zerami
+func (c *fileData) writeAt(p []byte, off int64, appendMode bool) (int, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if appendMode {
+		off = int64(len(c.data))
+	}
+	if off < 0 {
+		return 0, off, ErrInvalidOffset
+	}
+	// porównanie z różnicą zamiast sumy off+len(p), która mogłaby się przepełnić
+	if off > MaxFileSize || int64(len(p)) > MaxFileSize-off {
+		return 0, off, ErrFileTooLarge
+	}
+	end := off + int64(len(p))
+	if end > int64(len(c.data)) {
+		grown := make([]byte, end)
+		copy(grown, c.data)
+		c.data = grown
+	}
+	copy(c.data[off:], p)
+	c.modifiedAt = time.Now()
+	return len(p), end, nil
+}
+
+func (h *FileHandle) Seek(offset int64, whence int) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return 0, os.ErrClosed
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += h.offset
+	case io.SeekEnd:
+		h.content.mu.RLock()
+		offset += int64(len(h.content.data))
+		h.content.mu.RUnlock()
+	default:
+		return 0, ErrInvalidOffset
+	}
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+	h.offset = offset
+	return offset, nil
+}
+
+func (h *FileHandle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+	return nil
 }
 
 func main() {
 	vfs := NewVirtualFileSystem()
 	vfs.CreateFolder("/", "docs")
 	vfs.CreateFile("/docs/", "file1.txt", []byte("Hello, world!"))
-	vfs.CreateSymlink("/", "linkToDocs", "/docs/file1.txt")
+	vfs.CreateSymlink("/", "linkToDocs", "docs")
+	vfs.CreateSymlink("/", "linkToFile", "/docs/file1.txt")
+	vfs.CreateHardLink("/", "hardlink.txt", "/docs/file1.txt")
+	vfs.CreateSymlink("/", "loop", "loop")
+	vfs.CreateFolder("/", "archive")
+	vfs.Copy("/docs", "/archive/docs-copy", true, false)
+	vfs.Rename("/archive/docs-copy", "old-docs", false)
+	if err := vfs.Move("/docs", "/docs/inner", false); err != nil {
+		fmt.Println("Error moving item:", err)
+	}
 
-	item, err := vfs.FindItem("/docs/file1.txt")
+	item, err := vfs.FindItem("/linkToDocs/file1.txt")
 	if err == nil {
-		fmt.Println("Found item:", item.Name())
+		fmt.Println("Found item:", item.Path())
 	} else {
 		fmt.Println("Error finding item:", err)
 	}
+	if handle, err := vfs.OpenFile("/docs/log.txt", os.O_WRONLY|os.O_CREATE|os.O_APPEND); err == nil {
+		fmt.Fprintf(handle, "line %d\n", 1)
+		fmt.Fprintf(handle, "line %d\n", 2)
+		handle.Close()
+	}
+	if handle, err := vfs.OpenFile("/docs/log.txt", os.O_RDONLY); err == nil {
+		content, _ := io.ReadAll(handle)
+		fmt.Printf("log.txt: %q\n", content)
+		handle.Close()
+	}
+	target, _ := vfs.Readlink("/linkToFile")
+	fmt.Println("linkToFile ->", target)
+	_, err = vfs.FindItem("/loop")
+	fmt.Println("Error finding item:", err)
 
 	vfs.DeleteItem("/docs/file1.txt")
-	item, err = vfs.FindItem("/docs/file1.txt")
+	_, err = vfs.FindItem("/linkToFile")
+	fmt.Println("Error finding item:", err)
+	item, err = vfs.FindItem("/archive/old-docs/file1.txt")
 	if err == nil {
-		fmt.Println("Found item:", item.Name())
+		fmt.Println("Found item:", item.Path(), "size:", item.Size())
+	} else {
+		fmt.Println("Error finding item:", err)
+	}
+	item, err = vfs.FindItem("/hardlink.txt")
+	if err == nil {
+		fmt.Println("Found item:", item.Name(), "size:", item.Size(), "links:", item.(*Plik).Links())
 	} else {
 		fmt.Println("Error finding item:", err)
 	}
 
+	// dwóch użytkowników z własnymi katalogami domowymi i wspólnym /tmp
+	alice := vfs.WithCredentials(Credentials{UID: 1000, GID: 1000})
+	bob := vfs.WithCredentials(Credentials{UID: 1001, GID: 1001})
+	vfs.CreateFolder("/", "home")
+	vfs.CreateFolder("/home", "alice")
+	vfs.Chown("/home/alice", 1000, 1000)
+	vfs.Chmod("/home/alice", 0o700)
+	vfs.CreateFolder("/", "tmp")
+	vfs.Chmod("/tmp", 0o777|fs.ModeSticky)
+	alice.CreateFile("/home/alice", "notes.txt", []byte("private"))
+	alice.CreateFile("/tmp", "shared.txt", []byte("public"))
+	if _, err := bob.OpenFile("/home/alice/notes.txt", os.O_RDONLY); err != nil {
+		fmt.Println("bob: open notes.txt:", err)
+	}
+	if err := bob.DeleteItem("/tmp/shared.txt"); err != nil {
+		fmt.Println("bob: delete shared.txt:", err)
+	}
+	if err := bob.Chmod("/tmp/shared.txt", 0o666); err != nil {
+		fmt.Println("bob: chmod shared.txt:", err)
+	}
+	if info, err := bob.Stat("tmp/shared.txt"); err == nil {
+		uid, gid := info.Sys().(FileSystemItem).Owner()
+		fmt.Println("shared.txt:", info.Mode(), "owner:", uid, "group:", gid)
+	}
 }
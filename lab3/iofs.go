@@ -0,0 +1,218 @@
+package main
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// VirtualFileSystem implementuje interfejsy pakietu io/fs, dzięki czemu działa
+// z fs.WalkDir, fs.Glob, http.FS czy template.ParseFS. Nazwy plików podaje się
+// tak jak w io/fs: bez ukośnika na początku, a korzeń to ".".
+var (
+	_ fs.FS         = (*VirtualFileSystem)(nil)
+	_ fs.ReadDirFS  = (*VirtualFileSystem)(nil)
+	_ fs.StatFS     = (*VirtualFileSystem)(nil)
+	_ fs.ReadFileFS = (*VirtualFileSystem)(nil)
+	_ fs.ReadLinkFS = (*VirtualFileSystem)(nil)
+)
+
+// fileInfo opisuje element systemu plików jako fs.FileInfo i fs.DirEntry.
+// Nazwa jest przechowywana osobno, bo element znaleziony przez dowiązanie
+// symboliczne występuje pod nazwą dowiązania.
+type fileInfo struct {
+	name string
+	item FileSystemItem
+}
+
+func newFileInfo(name string, item FileSystemItem) *fileInfo {
+	return &fileInfo{name: path.Base(name), item: item}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.item.Size() }
+func (fi *fileInfo) ModTime() time.Time { return fi.item.ModifiedAt() }
+func (fi *fileInfo) IsDir() bool        { return fi.Mode().IsDir() }
+func (fi *fileInfo) Sys() any           { return fi.item }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.item.Mode() }
+
+// metody fs.DirEntry
+func (fi *fileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi *fileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+func (fi *fileInfo) String() string             { return fs.FormatDirEntry(fi) }
+
+// fsLookup sprawdza nazwę według reguł io/fs i wyszukuje element
+func (vfs *VirtualFileSystem) fsLookup(op, name string, follow bool) (FileSystemItem, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	item, err := vfs.resolve(name, follow)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return item, nil
+}
+
+// Open otwiera plik albo katalog do odczytu. Katalog zwraca fs.ReadDirFile,
+// a plik *FileHandle.
+func (vfs *VirtualFileSystem) Open(name string) (fs.File, error) {
+	item, err := vfs.fsLookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if folder, ok := item.(*Katalog); ok {
+		if err := vfs.cred.check(folder, permRead); err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &dirHandle{info: newFileInfo(name, folder), entries: readDir(folder)}, nil
+	}
+	handle, err := vfs.OpenFile(name, os.O_RDONLY)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	handle.name = path.Base(name)
+	return handle, nil
+}
+
+// Stat zwraca informacje o elemencie, rozwijając dowiązania symboliczne
+func (vfs *VirtualFileSystem) Stat(name string) (fs.FileInfo, error) {
+	item, err := vfs.fsLookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(name, item), nil
+}
+
+// Lstat działa jak Stat, ale opisuje samo dowiązanie symboliczne
+func (vfs *VirtualFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	item, err := vfs.fsLookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(name, item), nil
+}
+
+// ReadLink to wariant Readlink przyjmujący nazwy według reguł io/fs
+func (vfs *VirtualFileSystem) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	target, err := vfs.Readlink(name)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return target, nil
+}
+
+// ReadDir zwraca zawartość katalogu posortowaną według nazw; wymaga prawa r
+func (vfs *VirtualFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	item, err := vfs.fsLookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	folder, ok := item.(*Katalog)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: ErrNotDirectory}
+	}
+	if err := vfs.cred.check(folder, permRead); err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return readDir(folder), nil
+}
+
+// ReadFile zwraca kopię zawartości pliku; wymaga prawa r
+func (vfs *VirtualFileSystem) ReadFile(name string) ([]byte, error) {
+	item, err := vfs.fsLookup("read", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, isDir := item.(*Katalog); !isDir && !vfs.cred.can(item, permRead) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: ErrPermissionDenied}
+	}
+	switch it := item.(type) {
+	case *Plik:
+		it.content.mu.RLock()
+		defer it.content.mu.RUnlock()
+		return append([]byte(nil), it.content.data...), nil
+	case *ReadOnlyFile:
+		return append([]byte(nil), it.data...), nil
+	case *Katalog:
+		return nil, &fs.PathError{Op: "read", Path: name, Err: ErrIsDirectory}
+	}
+	return nil, &fs.PathError{Op: "read", Path: name, Err: ErrNotImplemented}
+}
+
+func readDir(folder *Katalog) []fs.DirEntry {
+	items := folder.snapshot()
+	entries := make([]fs.DirEntry, 0, len(items))
+	for name, item := range items {
+		entries = append(entries, &fileInfo{name: name, item: item})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+// Stat zwraca informacje o otwartym pliku
+func (h *FileHandle) Stat() (fs.FileInfo, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, os.ErrClosed
+	}
+	name := h.name
+	if name == "" {
+		name = h.item.Name()
+	}
+	return &fileInfo{name: name, item: h.item}, nil
+}
+
+// dirHandle to otwarty katalog; ReadDir zwraca kolejne porcje wpisów
+type dirHandle struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+	closed  bool
+}
+
+func (d *dirHandle) Stat() (fs.FileInfo, error) {
+	if d.closed {
+		return nil, os.ErrClosed
+	}
+	return d.info, nil
+}
+
+func (d *dirHandle) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: ErrIsDirectory}
+}
+
+func (d *dirHandle) Close() error {
+	if d.closed {
+		return os.ErrClosed
+	}
+	d.closed = true
+	return nil
+}
+
+// ReadDir zwraca do n kolejnych wpisów i io.EOF po ostatnim, a przy n <= 0
+// wszystkie pozostałe wpisy
+func (d *dirHandle) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, os.ErrClosed
+	}
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// TestFS sprawdza implementację io/fs standardowym testem fstest.TestFS na
+// drzewie z plikami, zagnieżdżonymi katalogami i dowiązaniami symbolicznymi
+func TestFS(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	for _, err := range []error{
+		vfs.CreateFile("/", "readme.txt", []byte("hello")),
+		vfs.CreateFolder("/", "docs"),
+		vfs.CreateFile("/docs", "a.txt", []byte("a")),
+		vfs.CreateFile("/docs", "empty.txt", nil),
+		vfs.CreateFolder("/docs", "nested"),
+		vfs.CreateFolder("/docs/nested", "deeper"),
+		vfs.CreateFile("/docs/nested/deeper", "b.txt", []byte("bbb")),
+		vfs.CreateFolder("/", "empty"),
+		vfs.CreateSymlink("/", "link.txt", "docs/a.txt"),
+		vfs.CreateSymlink("/docs", "up", "../readme.txt"),
+		vfs.CreateHardLink("/docs/nested", "hard.txt", "/docs/a.txt"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := fstest.TestFS(vfs,
+		"readme.txt",
+		"docs/a.txt",
+		"docs/empty.txt",
+		"docs/nested/deeper/b.txt",
+		"docs/nested/hard.txt",
+		"link.txt",
+		"docs/up",
+		"empty",
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// fstest.TestFS sprawdza dowiązania tylko przez Lstat, więc cele
+	// i odczyt przez dowiązanie sprawdzamy osobno
+	links := []struct{ name, target, content string }{
+		{"link.txt", "docs/a.txt", "a"},
+		{"docs/up", "../readme.txt", "hello"},
+	}
+	for _, link := range links {
+		if target, err := fs.ReadLink(vfs, link.name); err != nil || target != link.target {
+			t.Errorf("ReadLink(%q) = %q, %v; want %q", link.name, target, err, link.target)
+		}
+		if data, err := fs.ReadFile(vfs, link.name); err != nil || string(data) != link.content {
+			t.Errorf("ReadFile(%q) = %q, %v; want %q", link.name, data, err, link.content)
+		}
+	}
+}
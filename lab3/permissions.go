@@ -0,0 +1,245 @@
+package main
+
+import (
+	"io/fs"
+	"slices"
+)
+
+// Credentials to tożsamość wywołującego: użytkownik, grupa główna i grupy
+// dodatkowe. Użytkownik o UID 0 (root) ma dostęp do wszystkiego.
+type Credentials struct {
+	UID    int
+	GID    int
+	Groups []int
+}
+
+// Root to tożsamość administratora, z którą działa NewVirtualFileSystem
+var Root = Credentials{}
+
+// Domyślne prawa dostępu nowych elementów
+const (
+	DefaultFileMode    fs.FileMode = 0o644
+	DefaultDirMode     fs.FileMode = 0o755
+	DefaultSymlinkMode fs.FileMode = 0o777
+)
+
+// Prawa sprawdzane w obrębie jednej trójki rwx
+const (
+	permRead  fs.FileMode = 4
+	permWrite fs.FileMode = 2
+	permExec  fs.FileMode = 1
+)
+
+// Bity trybu, które można zmienić przez Chmod
+const chmodMask = fs.ModePerm | fs.ModeSticky
+
+// attributes to prawa dostępu i właściciel elementu. Zmieniają się tylko
+// przez Chmod i Chown, więc chroni je jedna blokada attrsMu drzewa.
+type attributes struct {
+	mode fs.FileMode // bity praw dostępu i ewentualnie fs.ModeSticky
+	uid  int
+	gid  int
+}
+
+func newAttributes(mode fs.FileMode, owner Credentials) attributes {
+	return attributes{mode: mode, uid: owner.UID, gid: owner.GID}
+}
+
+// get zwraca kopię atrybutów chronionych blokadą attrsMu drzewa tree
+func (a *attributes) get(tree *fileTree) attributes {
+	if tree != nil {
+		tree.attrsMu.RLock()
+		defer tree.attrsMu.RUnlock()
+	}
+	return *a
+}
+
+// perm zwraca prawa dostępu razem z bitem sticky
+func perm(a *attributes, tree *fileTree) fs.FileMode { return a.get(tree).mode }
+
+func owner(a *attributes, tree *fileTree) (uid, gid int) {
+	attrs := a.get(tree)
+	return attrs.uid, attrs.gid
+}
+
+// attrs zwraca atrybuty elementu i drzewo, którego blokada je chroni;
+// dowiązania twarde pliku współdzielą prawa dostępu, tak jak dane
+func (f *Plik) attrs() (*attributes, *fileTree)         { return &f.content.attributes, f.tree }
+func (d *Katalog) attrs() (*attributes, *fileTree)      { return &d.attributes, d.tree }
+func (s *SymLink) attrs() (*attributes, *fileTree)      { return &s.attributes, s.tree }
+func (r *ReadOnlyFile) attrs() (*attributes, *fileTree) { return &r.attributes, r.tree }
+
+// Owner zwraca identyfikatory właściciela i grupy elementu
+func (f *Plik) Owner() (uid, gid int)         { return owner(f.attrs()) }
+func (d *Katalog) Owner() (uid, gid int)      { return owner(d.attrs()) }
+func (s *SymLink) Owner() (uid, gid int)      { return owner(s.attrs()) }
+func (r *ReadOnlyFile) Owner() (uid, gid int) { return owner(r.attrs()) }
+
+func (f *Plik) Mode() fs.FileMode         { return perm(f.attrs()) }
+func (d *Katalog) Mode() fs.FileMode      { return fs.ModeDir | perm(d.attrs()) }
+func (s *SymLink) Mode() fs.FileMode      { return fs.ModeSymlink | perm(s.attrs()) }
+func (r *ReadOnlyFile) Mode() fs.FileMode { return perm(r.attrs()) }
+
+// attrsOf zwraca atrybuty elementu albo nil dla typów spoza tego pakietu
+func attrsOf(item FileSystemItem) (*attributes, *fileTree) {
+	if a, ok := item.(interface {
+		attrs() (*attributes, *fileTree)
+	}); ok {
+		return a.attrs()
+	}
+	return nil, nil
+}
+
+func (c Credentials) isRoot() bool { return c.UID == 0 }
+
+func (c Credentials) inGroup(gid int) bool {
+	return c.GID == gid || slices.Contains(c.Groups, gid)
+}
+
+// can sprawdza, czy wywołujący ma do elementu prawa want (kombinacja permRead,
+// permWrite i permExec) według trójki właściciela, grupy albo pozostałych
+func (c Credentials) can(item FileSystemItem, want fs.FileMode) bool {
+	if c.isRoot() {
+		return true
+	}
+	a, tree := attrsOf(item)
+	if a == nil {
+		return false
+	}
+	attrs := a.get(tree)
+	bits := attrs.mode
+	switch {
+	case c.UID == attrs.uid:
+		bits >>= 6
+	case c.inGroup(attrs.gid):
+		bits >>= 3
+	}
+	return bits&want == want
+}
+
+// check zwraca ErrPermissionDenied, gdy wywołujący nie ma do elementu praw want
+func (c Credentials) check(item FileSystemItem, want fs.FileMode) error {
+	if !c.can(item, want) {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// canRemove sprawdza, czy wywołujący może usunąć element z katalogu folder
+// albo go z niego przenieść. Potrzebne są prawa zapisu i przeszukiwania
+// katalogu, a w katalogu z bitem sticky (jak /tmp) trzeba też być
+// właścicielem elementu albo katalogu.
+func (c Credentials) canRemove(folder *Katalog, item FileSystemItem) error {
+	if err := c.check(folder, permWrite|permExec); err != nil {
+		return err
+	}
+	if c.isRoot() || folder.Mode()&fs.ModeSticky == 0 {
+		return nil
+	}
+	if dirUID, _ := folder.Owner(); dirUID == c.UID {
+		return nil
+	}
+	if itemUID, _ := item.Owner(); itemUID == c.UID {
+		return nil
+	}
+	return ErrPermissionDenied
+}
+
+// canRemoveTree sprawdza, czy wywołujący może usunąć całą zawartość katalogu
+func (c Credentials) canRemoveTree(item FileSystemItem) error {
+	dir, ok := item.(*Katalog)
+	if !ok {
+		return nil
+	}
+	for _, child := range dir.snapshot() {
+		if err := c.canRemove(dir, child); err != nil {
+			return err
+		}
+		if err := c.canRemoveTree(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canReadTree sprawdza, czy wywołujący może odczytać element, a dla katalogu
+// także całą jego zawartość; dowiązania symboliczne są kopiowane bez celu
+func (c Credentials) canReadTree(item FileSystemItem) error {
+	switch it := item.(type) {
+	case *SymLink:
+		return nil
+	case *Katalog:
+		if err := c.check(it, permRead|permExec); err != nil {
+			return err
+		}
+		for _, child := range it.snapshot() {
+			if err := c.canReadTree(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.check(item, permRead)
+}
+
+// WithCredentials zwraca sesję działającą na tym samym drzewie, w której każda
+// operacja jest sprawdzana względem praw użytkownika cred. Pozwala to
+// obsługiwać wielu użytkowników jednego systemu plików, każdego przez własną sesję.
+func (vfs *VirtualFileSystem) WithCredentials(cred Credentials) *VirtualFileSystem {
+	return &VirtualFileSystem{fileTree: vfs.fileTree, cred: cred}
+}
+
+// Credentials zwraca tożsamość, z którą działa sesja
+func (vfs *VirtualFileSystem) Credentials() Credentials { return vfs.cred }
+
+// Chmod zmienia prawa dostępu elementu, rozwijając dowiązania symboliczne.
+// Może to zrobić tylko właściciel elementu albo root.
+func (vfs *VirtualFileSystem) Chmod(path string, mode fs.FileMode) error {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	item, err := vfs.resolve(path, true)
+	if err != nil {
+		return err
+	}
+	a, _ := attrsOf(item)
+	if a == nil {
+		return ErrNotImplemented
+	}
+	vfs.attrsMu.Lock()
+	defer vfs.attrsMu.Unlock()
+	if !vfs.cred.isRoot() && vfs.cred.UID != a.uid {
+		return ErrPermissionDenied
+	}
+	a.mode = mode & chmodMask
+	return nil
+}
+
+// Chown zmienia właściciela i grupę elementu, rozwijając dowiązania
+// symboliczne; -1 pozostawia wartość bez zmian. Właściciela może zmienić
+// tylko root, a grupę także właściciel elementu, o ile sam do niej należy.
+func (vfs *VirtualFileSystem) Chown(path string, uid, gid int) error {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+	item, err := vfs.resolve(path, true)
+	if err != nil {
+		return err
+	}
+	a, _ := attrsOf(item)
+	if a == nil {
+		return ErrNotImplemented
+	}
+	vfs.attrsMu.Lock()
+	defer vfs.attrsMu.Unlock()
+	if !vfs.cred.isRoot() {
+		if vfs.cred.UID != a.uid || (uid != -1 && uid != a.uid) || (gid != -1 && !vfs.cred.inGroup(gid)) {
+			return ErrPermissionDenied
+		}
+	}
+	if uid != -1 {
+		a.uid = uid
+	}
+	if gid != -1 {
+		a.gid = gid
+	}
+	return nil
+}
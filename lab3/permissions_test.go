@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+var (
+	alice = Credentials{UID: 1000, GID: 1000}
+	bob   = Credentials{UID: 1001, GID: 1001}
+	carol = Credentials{UID: 1002, GID: 1002, Groups: []int{1000}}
+)
+
+// permTree buduje drzewo z prywatnym katalogiem domowym alice, wspólnym
+// katalogiem /tmp z bitem sticky i katalogiem /shared bez tego bitu
+func permTree(t *testing.T) *VirtualFileSystem {
+	t.Helper()
+	vfs := NewVirtualFileSystem()
+	as := vfs.WithCredentials(alice)
+	for _, err := range []error{
+		vfs.CreateFolder("/", "home"),
+		vfs.CreateFolder("/home", "alice"),
+		vfs.Chown("/home/alice", alice.UID, alice.GID),
+		vfs.Chmod("/home/alice", 0o700),
+		vfs.CreateFolder("/", "tmp"),
+		vfs.Chmod("/tmp", 0o777|fs.ModeSticky),
+		vfs.CreateFolder("/", "shared"),
+		vfs.Chmod("/shared", 0o777),
+		vfs.CreateFile("/", "rootonly", []byte("root")),
+		vfs.Chmod("/rootonly", 0),
+		as.CreateFile("/home/alice", "notes.txt", []byte("private")),
+		as.CreateFile("/tmp", "a.txt", []byte("alice")),
+		as.CreateFile("/tmp", "secret", []byte("secret")),
+		as.Chmod("/tmp/secret", 0o600),
+		as.CreateFile("/tmp", "group", []byte("group")),
+		as.Chmod("/tmp/group", 0o660),
+		as.CreateFile("/shared", "a.txt", []byte("alice")),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return vfs
+}
+
+func open(vfs *VirtualFileSystem, path string, flag int) error {
+	h, err := vfs.OpenFile(path, flag)
+	if err == nil {
+		h.Close()
+	}
+	return err
+}
+
+func TestPermissions(t *testing.T) {
+	tests := []struct {
+		name string
+		cred Credentials
+		op   func(vfs *VirtualFileSystem) error
+		want error
+	}{
+		// odczyt i zapis pliku
+		{"other reads 0644", bob, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/tmp/a.txt", os.O_RDONLY)
+		}, nil},
+		{"other writes 0644", bob, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/tmp/a.txt", os.O_WRONLY)
+		}, ErrPermissionDenied},
+		{"other truncates 0644", bob, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/tmp/a.txt", os.O_RDONLY|os.O_TRUNC)
+		}, ErrPermissionDenied},
+		{"other reads 0600", bob, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/tmp/secret", os.O_RDONLY)
+		}, ErrPermissionDenied},
+		{"other reads 0600 through io/fs", bob, func(vfs *VirtualFileSystem) error {
+			_, err := vfs.ReadFile("tmp/secret")
+			return err
+		}, ErrPermissionDenied},
+		{"owner writes 0600", alice, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/tmp/secret", os.O_RDWR|os.O_APPEND)
+		}, nil},
+		{"group member writes 0660", carol, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/tmp/group", os.O_RDWR)
+		}, nil},
+		{"other reads 0660", bob, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/tmp/group", os.O_RDONLY)
+		}, ErrPermissionDenied},
+		{"owner reads 0000", alice, func(vfs *VirtualFileSystem) error {
+			if err := vfs.Chmod("/tmp/a.txt", 0); err != nil {
+				return err
+			}
+			return open(vfs, "/tmp/a.txt", os.O_RDONLY)
+		}, ErrPermissionDenied},
+
+		// przeszukiwanie katalogu i tworzenie w nim elementów
+		{"other finds in 0700 folder", bob, func(vfs *VirtualFileSystem) error {
+			_, err := vfs.FindItem("/home/alice/notes.txt")
+			return err
+		}, ErrPermissionDenied},
+		{"other creates in 0700 folder", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.CreateFile("/home/alice", "x", nil)
+		}, ErrPermissionDenied},
+		{"other creates in 0755 folder", bob, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/home/x", os.O_WRONLY|os.O_CREATE)
+		}, ErrPermissionDenied},
+		{"other lists 0700 folder", bob, func(vfs *VirtualFileSystem) error {
+			_, err := vfs.ReadDir("home/alice")
+			return err
+		}, ErrPermissionDenied},
+		{"group member finds in 0710 folder", carol, func(vfs *VirtualFileSystem) error {
+			if err := vfs.WithCredentials(alice).Chmod("/home/alice", 0o710); err != nil {
+				return err
+			}
+			_, err := vfs.FindItem("/home/alice/notes.txt")
+			return err
+		}, nil},
+		{"other symlinks into 0700 folder", bob, func(vfs *VirtualFileSystem) error {
+			if err := vfs.CreateSymlink("/tmp", "peek", "/home/alice/notes.txt"); err != nil {
+				return err
+			}
+			return open(vfs, "/tmp/peek", os.O_RDONLY)
+		}, ErrPermissionDenied},
+		{"other hard links from 0700 folder", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.CreateHardLink("/tmp", "peek", "/home/alice/notes.txt")
+		}, ErrPermissionDenied},
+
+		// bit sticky: w /tmp usuwa tylko właściciel elementu albo katalogu
+		{"other deletes in sticky folder", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/tmp/a.txt")
+		}, ErrPermissionDenied},
+		{"other moves out of sticky folder", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.Move("/tmp/a.txt", "/shared/b.txt", false)
+		}, ErrPermissionDenied},
+		{"other renames in sticky folder", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.Rename("/tmp/a.txt", "b.txt", false)
+		}, ErrPermissionDenied},
+		{"other overwrites in sticky folder", bob, func(vfs *VirtualFileSystem) error {
+			if err := vfs.CreateFile("/tmp", "bob.txt", nil); err != nil {
+				return err
+			}
+			return vfs.Move("/tmp/bob.txt", "/tmp/a.txt", true)
+		}, ErrPermissionDenied},
+		{"owner deletes in sticky folder", alice, func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/tmp/a.txt")
+		}, nil},
+		{"other deletes in writable folder", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/shared/a.txt")
+		}, nil},
+		{"other deletes from 0755 folder", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/rootonly")
+		}, ErrPermissionDenied},
+
+		// Chmod i Chown
+		{"other chmods", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.Chmod("/tmp/a.txt", 0o666)
+		}, ErrPermissionDenied},
+		{"group member chmods", carol, func(vfs *VirtualFileSystem) error {
+			return vfs.Chmod("/tmp/group", 0o666)
+		}, ErrPermissionDenied},
+		{"owner chmods", alice, func(vfs *VirtualFileSystem) error {
+			return vfs.Chmod("/tmp/a.txt", 0o600)
+		}, nil},
+		{"owner gives file away", alice, func(vfs *VirtualFileSystem) error {
+			return vfs.Chown("/tmp/a.txt", bob.UID, -1)
+		}, ErrPermissionDenied},
+		{"owner changes group to a foreign one", alice, func(vfs *VirtualFileSystem) error {
+			return vfs.Chown("/tmp/a.txt", -1, bob.GID)
+		}, ErrPermissionDenied},
+		{"owner changes group to own", carol, func(vfs *VirtualFileSystem) error {
+			if err := vfs.CreateFile("/tmp", "c.txt", nil); err != nil {
+				return err
+			}
+			return vfs.Chown("/tmp/c.txt", carol.UID, 1000)
+		}, nil},
+		{"other chowns", bob, func(vfs *VirtualFileSystem) error {
+			return vfs.Chown("/tmp/a.txt", bob.UID, bob.GID)
+		}, ErrPermissionDenied},
+
+		// root ma dostęp do wszystkiego
+		{"root reads 0000", Root, func(vfs *VirtualFileSystem) error {
+			return open(vfs, "/rootonly", os.O_RDWR)
+		}, nil},
+		{"root finds in 0700 folder", Root, func(vfs *VirtualFileSystem) error {
+			_, err := vfs.FindItem("/home/alice/notes.txt")
+			return err
+		}, nil},
+		{"root deletes in sticky folder", Root, func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/tmp/secret")
+		}, nil},
+		{"root chowns", Root, func(vfs *VirtualFileSystem) error {
+			return vfs.Chown("/tmp/a.txt", bob.UID, bob.GID)
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vfs := permTree(t).WithCredentials(tt.cred)
+			if err := tt.op(vfs); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestRecursivePermissions sprawdza, że usunięcie i kopiowanie katalogu
+// wymagają praw do całej jego zawartości i przy ich braku niczego nie zmieniają
+func TestRecursivePermissions(t *testing.T) {
+	vfs := permTree(t)
+	as, bs := vfs.WithCredentials(alice), vfs.WithCredentials(bob)
+	for _, err := range []error{
+		as.CreateFolder("/shared", "dir"),
+		as.CreateFolder("/shared/dir", "inner"),
+		as.CreateFile("/shared/dir/inner", "f", []byte("f")),
+		as.Chmod("/shared/dir/inner", 0o555),
+		as.CreateFolder("/shared", "pub"),
+		as.Chmod("/shared/pub", 0o777),
+		as.CreateFile("/shared/pub", "secret", []byte("secret")),
+		as.Chmod("/shared/pub/secret", 0o600),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// alice nie może opróżnić katalogu inner, do którego nie ma prawa zapisu
+	if err := as.DeleteItem("/shared/dir"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("delete of a folder with a read-only subfolder: got error %v, want %v", err, ErrPermissionDenied)
+	}
+	// bob nie może usunąć cudzych elementów z katalogu 0755
+	if err := bs.DeleteItem("/shared/dir"); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("delete of a foreign folder: got error %v, want %v", err, ErrPermissionDenied)
+	}
+	if _, err := vfs.FindItem("/shared/dir/inner/f"); err != nil {
+		t.Errorf("failed delete removed part of the tree: %v", err)
+	}
+
+	// bob nie może skopiować katalogu z plikiem, którego nie może odczytać
+	if err := bs.Copy("/shared/pub", "/shared/copy", true, false); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("copy of a folder with an unreadable file: got error %v, want %v", err, ErrPermissionDenied)
+	}
+	if _, err := vfs.FindItem("/shared/copy"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("failed copy left %v", err)
+	}
+	// ani katalogu, którego nie może przeszukać
+	if err := bs.Copy("/home/alice", "/shared/copy", true, false); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("copy of a 0700 folder: got error %v, want %v", err, ErrPermissionDenied)
+	}
+
+	// ale w katalogu 0777 bez bitu sticky może go w całości usunąć
+	if err := bs.DeleteItem("/shared/pub"); err != nil {
+		t.Errorf("delete of a writable folder: %v", err)
+	}
+
+	// kopia należy do kopiującego i zachowuje prawa dostępu oryginału
+	if err := as.Copy("/shared/dir", "/tmp/copy", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := vfs.WithCredentials(carol).Copy("/tmp/copy/inner/f", "/tmp/f", false, false); err != nil {
+		t.Fatal(err)
+	}
+	item, err := vfs.FindItem("/tmp/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, gid := item.Owner(); uid != carol.UID || gid != carol.GID || item.Mode() != DefaultFileMode {
+		t.Errorf("copy has owner %d:%d and mode %v, want %d:%d and %v", uid, gid, item.Mode(), carol.UID, carol.GID, DefaultFileMode)
+	}
+
+	// root usuwa wszystko
+	if err := vfs.DeleteItem("/shared/dir"); err != nil {
+		t.Errorf("root delete: %v", err)
+	}
+}
+
+// TestHardLinksSharePermissions sprawdza, że dowiązania twarde mają wspólne
+// prawa dostępu, więc nie da się nimi obejść Chmod
+func TestHardLinksSharePermissions(t *testing.T) {
+	vfs := permTree(t)
+	as := vfs.WithCredentials(alice)
+	if err := as.CreateHardLink("/tmp", "link", "/tmp/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := as.Chmod("/tmp/a.txt", 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := open(vfs.WithCredentials(bob), "/tmp/link", os.O_RDONLY); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("read through a hard link after chmod: got error %v, want %v", err, ErrPermissionDenied)
+	}
+}
@@ -0,0 +1,988 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentOperations uruchamia kilkaset goroutine wykonujących naraz
+// operacje na kilku wspólnych katalogach. Test jest przeznaczony do
+// uruchamiania z detektorem wyścigów: go test -race
+func TestConcurrentOperations(t *testing.T) {
+	const (
+		goroutines = 300
+		iterations = 20
+		dirs       = 4
+	)
+	vfs := NewVirtualFileSystem()
+	for d := range dirs {
+		if err := vfs.CreateFolder("/", fmt.Sprint("d", d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// błędy wynikające z wyścigu o te same nazwy są oczekiwane; każdy inny
+	// błąd oznacza niespójny stan drzewa
+	expected := func(err error) bool {
+		return err == nil || errors.Is(err, ErrItemExists) || errors.Is(err, ErrItemNotFound) ||
+			errors.Is(err, ErrNotDirectory) || errors.Is(err, ErrIsDirectory)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dir := fmt.Sprint("/d", g%dirs)
+			other := fmt.Sprint("/d", (g+1)%dirs)
+			for i := range iterations {
+				name := fmt.Sprint("f", (g+i)%10)
+				var err error
+				switch i % 7 {
+				case 0:
+					err = vfs.CreateFile(dir, name, []byte("data"))
+				case 1:
+					err = vfs.Move(dir+"/"+name, other+"/"+name, true)
+				case 2:
+					err = vfs.Copy(dir+"/"+name, other+"/copy-"+name, false, true)
+				case 3:
+					var h *FileHandle
+					h, err = vfs.OpenFile(dir+"/"+name, os.O_RDWR|os.O_CREATE|os.O_APPEND)
+					if err == nil {
+						if _, err = h.Write([]byte("x")); err == nil {
+							_, err = io.ReadAll(h)
+						}
+						h.Close()
+					}
+				case 4:
+					_, err = vfs.ReadDir(dir[1:])
+				case 5:
+					_, err = vfs.FindItem(dir + "/" + name)
+				case 6:
+					err = vfs.DeleteItem(dir + "/" + name)
+				}
+				if !expected(err) {
+					errs <- fmt.Errorf("goroutine %d, iteration %d: %w", g, i, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// po zakończeniu każdy element musi być osiągalny pod ścieżką, którą zwraca Path
+	for d := range dirs {
+		folder, err := vfs.FindFolder(fmt.Sprint("/d", d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range folder.Items() {
+			found, err := vfs.FindItem(item.Path())
+			if err != nil || found != item {
+				t.Errorf("FindItem(%q) = %v, %v; want the item itself", item.Path(), found, err)
+			}
+		}
+	}
+}
+
+// TestTreesDoNotBlockEachOther sprawdza, że operacje na jednym systemie
+// plików nie czekają na operacje na innym. Kopiowanie dużego katalogu
+// trzyma blokadę drzewa a na wyłączność; gdyby drzewa dzieliły blokady,
+// operacje na drzewie b skończyłyby się dopiero po kopiowaniu.
+func TestTreesDoNotBlockEachOther(t *testing.T) {
+	a, b := NewVirtualFileSystem(), NewVirtualFileSystem()
+	if err := a.CreateFolder("/", "big"); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 20000 {
+		if err := a.CreateFile("/big", fmt.Sprintf("f%d", i), []byte("data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	copied := make(chan time.Time)
+	go func() {
+		if err := a.Copy("/big", "/copy", true, false); err != nil {
+			t.Error(err)
+		}
+		copied <- time.Now()
+	}()
+	// czas na rozpoczęcie kopiowania, które od razu zajmuje blokadę drzewa a
+	time.Sleep(10 * time.Millisecond)
+
+	for i := range 100 {
+		name := fmt.Sprintf("f%d", i)
+		for _, err := range []error{
+			b.CreateFile("/", name, nil),
+			b.Chmod("/"+name, 0o600),
+			b.Rename("/"+name, "g", false),
+			b.DeleteItem("/g"),
+		} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if item, err := b.FindItem("/"); err != nil || item.Path() != "/" || item.Mode() != DefaultDirMode|fs.ModeDir {
+			t.Fatalf("FindItem(/) = %v, %v", item, err)
+		}
+	}
+	finished := time.Now()
+	if copiedAt := <-copied; copiedAt.Before(finished) {
+		t.Errorf("operations on tree b finished %v after copying in tree a", finished.Sub(copiedAt))
+	}
+}
+
+// benchmarkTree buduje drzewo 100 katalogów po 10 podkatalogów po 100 plików
+// (101 101 elementów) i zwraca ścieżki wszystkich plików
+func benchmarkTree(b *testing.B) (*VirtualFileSystem, []string) {
+	b.Helper()
+	vfs := NewVirtualFileSystem()
+	paths := make([]string, 0, 100*10*100)
+	for i := range 100 {
+		dir := fmt.Sprint("/dir", i)
+		if err := vfs.CreateFolder("/", dir[1:]); err != nil {
+			b.Fatal(err)
+		}
+		for j := range 10 {
+			sub := fmt.Sprint(dir, "/sub", j)
+			if err := vfs.CreateFolder(dir, fmt.Sprint("sub", j)); err != nil {
+				b.Fatal(err)
+			}
+			for k := range 100 {
+				name := fmt.Sprint("file", k, ".txt")
+				if err := vfs.CreateFile(sub, name, nil); err != nil {
+					b.Fatal(err)
+				}
+				paths = append(paths, sub+"/"+name)
+			}
+		}
+	}
+	return vfs, paths
+}
+
+// BenchmarkFindItem mierzy wyszukiwanie przez przejście elementów ścieżki;
+// koszt zależy od głębokości ścieżki, a nie od liczby elementów drzewa
+func BenchmarkFindItem(b *testing.B) {
+	vfs, paths := benchmarkTree(b)
+	b.ResetTimer()
+	for i := range b.N {
+		if _, err := vfs.FindItem(paths[i*7919%len(paths)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFindItemScan mierzy dla porównania dawne wyszukiwanie, które
+// przeglądało całe drzewo i porównywało ścieżki elementów
+func BenchmarkFindItemScan(b *testing.B) {
+	vfs, paths := benchmarkTree(b)
+	b.ResetTimer()
+	for i := range b.N {
+		if _, err := scanTree(vfs.root, paths[i*7919%len(paths)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func scanTree(folder *Katalog, path string) (FileSystemItem, error) {
+	for _, item := range folder.items {
+		if item.Path() == path {
+			return item, nil
+		}
+		if subFolder, ok := item.(*Katalog); ok {
+			if found, err := scanTree(subFolder, path); err == nil {
+				return found, nil
+			}
+		}
+	}
+	return nil, ErrItemNotFound
+}
+
+// pathTree buduje drzewo /p/inp, /p/q/ i /f z dowiązaniem /lq -> /p/q
+func pathTree(t *testing.T) *VirtualFileSystem {
+	t.Helper()
+	vfs := NewVirtualFileSystem()
+	for _, err := range []error{
+		vfs.CreateFolder("/", "p"),
+		vfs.CreateFolder("/p", "q"),
+		vfs.CreateFile("/p", "inp", []byte("in p")),
+		vfs.CreateFile("/", "f", nil),
+		vfs.CreateSymlink("/", "lq", "/p/q"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return vfs
+}
+
+// TestPathRules sprawdza, że wszystkie operacje rozumieją ścieżkę tak samo
+// jak FindItem: ".." po dowiązaniu prowadzi do rodzica jego celu, a ukośnik
+// na końcu wymaga katalogu
+func TestPathRules(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(vfs *VirtualFileSystem) error
+		want error
+	}{
+		{"find through symlink parent", func(vfs *VirtualFileSystem) error {
+			item, err := vfs.FindItem("/lq/../inp")
+			if err == nil && item.Path() != "/p/inp" {
+				t.Errorf("FindItem found %s, want /p/inp", item.Path())
+			}
+			return err
+		}, nil},
+		{"delete through symlink parent", func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/lq/../inp")
+		}, nil},
+		{"rename through symlink parent", func(vfs *VirtualFileSystem) error {
+			if err := vfs.Rename("/lq/../inp", "renamed", false); err != nil {
+				return err
+			}
+			_, err := vfs.FindItem("/p/renamed")
+			return err
+		}, nil},
+		{"move through symlink parent", func(vfs *VirtualFileSystem) error {
+			if err := vfs.Move("/lq/../inp", "/lq/../q/moved", false); err != nil {
+				return err
+			}
+			_, err := vfs.FindItem("/p/q/moved")
+			return err
+		}, nil},
+		{"copy through symlink parent", func(vfs *VirtualFileSystem) error {
+			if err := vfs.Copy("/lq/../inp", "/copy", false, false); err != nil {
+				return err
+			}
+			_, err := vfs.FindItem("/copy")
+			return err
+		}, nil},
+		{"create through symlink parent", func(vfs *VirtualFileSystem) error {
+			h, err := vfs.OpenFile("/lq/../created", os.O_WRONLY|os.O_CREATE)
+			if err != nil {
+				return err
+			}
+			h.Close()
+			_, err = vfs.FindItem("/p/created")
+			return err
+		}, nil},
+		{"folder with trailing slash", func(vfs *VirtualFileSystem) error {
+			_, err := vfs.FindFolder("/p/")
+			return err
+		}, nil},
+		{"symlink to folder with trailing slash", func(vfs *VirtualFileSystem) error {
+			_, err := vfs.FindFolder("/lq/")
+			return err
+		}, nil},
+		{"find file with trailing slash", func(vfs *VirtualFileSystem) error {
+			_, err := vfs.FindItem("/f/")
+			return err
+		}, ErrNotDirectory},
+		{"find file with trailing dot", func(vfs *VirtualFileSystem) error {
+			_, err := vfs.FindItem("/f/.")
+			return err
+		}, ErrNotDirectory},
+		{"delete file with trailing slash", func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/f/")
+		}, ErrNotDirectory},
+		{"move file with trailing slash", func(vfs *VirtualFileSystem) error {
+			return vfs.Move("/f/", "/g", false)
+		}, ErrNotDirectory},
+		{"open file with trailing slash", func(vfs *VirtualFileSystem) error {
+			_, err := vfs.OpenFile("/f/", os.O_RDONLY)
+			return err
+		}, ErrNotDirectory},
+		{"create file with trailing slash", func(vfs *VirtualFileSystem) error {
+			_, err := vfs.OpenFile("/new/", os.O_WRONLY|os.O_CREATE)
+			return err
+		}, ErrIsDirectory},
+		{"delete root", func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/")
+		}, ErrPermissionDenied},
+		{"delete dot-dot", func(vfs *VirtualFileSystem) error {
+			return vfs.DeleteItem("/p/q/..")
+		}, ErrInvalidName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(pathTree(t)); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteBeyondMaxFileSize sprawdza, że zapis z ogromnym przesunięciem
+// zwraca błąd zamiast rezerwować pamięć albo przepełniać off+len(p)
+func TestWriteBeyondMaxFileSize(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	h, err := vfs.OpenFile("/f", os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	for _, off := range []int64{MaxFileSize, 1 << 62, 1<<63 - 1} {
+		if _, err := h.WriteAt([]byte("x"), off); !errors.Is(err, ErrFileTooLarge) {
+			t.Errorf("WriteAt at %d: got error %v, want %v", off, err, ErrFileTooLarge)
+		}
+	}
+	if _, err := h.Seek(1<<62, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Write([]byte("x")); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Write after Seek: got error %v, want %v", err, ErrFileTooLarge)
+	}
+	if pos, _ := h.Seek(0, io.SeekCurrent); pos != 1<<62 {
+		t.Errorf("failed Write moved the offset to %d", pos)
+	}
+}
+
+// TestOpenHandleOutlivesDelete sprawdza, że otwarty uchwyt zachowuje dane
+// pliku po usunięciu jego ostatniego dowiązania
+func TestOpenHandleOutlivesDelete(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	if err := vfs.CreateFile("/", "f", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	h, err := vfs.OpenFile("/f", os.O_RDWR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	if err := vfs.DeleteItem("/f"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.WriteAt([]byte("!"), 4); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(h)
+	if err != nil || string(data) != "data!" {
+		t.Errorf("read %q, %v after delete; want %q", data, err, "data!")
+	}
+}
+
+// symlinkTree buduje drzewo z dowiązaniami bezwzględnymi, względnymi,
+// wiszącymi i zapętlonymi
+func symlinkTree(t *testing.T) *VirtualFileSystem {
+	t.Helper()
+	vfs := NewVirtualFileSystem()
+	for _, err := range []error{
+		vfs.CreateFolder("/", "docs"),
+		vfs.CreateFile("/docs", "a.txt", []byte("A")),
+		vfs.CreateFolder("/docs", "sub"),
+		vfs.CreateFile("/docs/sub", "b.txt", []byte("B")),
+		vfs.CreateSymlink("/", "abs", "/docs/a.txt"),
+		vfs.CreateSymlink("/", "rel", "docs/a.txt"),
+		vfs.CreateSymlink("/docs/sub", "up", "../a.txt"),
+		vfs.CreateSymlink("/docs/sub", "parent", ".."),
+		vfs.CreateSymlink("/", "dir", "/docs/sub"),
+		vfs.CreateSymlink("/", "chain", "abs"),
+		vfs.CreateSymlink("/", "dangling", "/missing"),
+		vfs.CreateSymlink("/", "empty", ""),
+		vfs.CreateSymlink("/", "self", "self"),
+		vfs.CreateSymlink("/", "loopA", "loopB"),
+		vfs.CreateSymlink("/", "loopB", "/loopA"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// łańcuchy dokładnie MaxSymlinkDepth i MaxSymlinkDepth+1 dowiązań
+	for _, n := range []int{MaxSymlinkDepth, MaxSymlinkDepth + 1} {
+		for i := 1; i <= n; i++ {
+			target := fmt.Sprintf("c%d-%d", n, i+1)
+			if i == n {
+				target = "/docs/a.txt"
+			}
+			if err := vfs.CreateSymlink("/", fmt.Sprintf("c%d-%d", n, i), target); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return vfs
+}
+
+func TestSymlinkResolution(t *testing.T) {
+	vfs := symlinkTree(t)
+	tests := []struct {
+		path string
+		want string // ścieżka znalezionego elementu
+		err  error
+	}{
+		{"/abs", "/docs/a.txt", nil},
+		{"/rel", "/docs/a.txt", nil},
+		{"/docs/sub/up", "/docs/a.txt", nil},
+		{"/docs/sub/parent", "/docs/", nil},
+		{"/docs/sub/parent/a.txt", "/docs/a.txt", nil},
+		{"/dir/b.txt", "/docs/sub/b.txt", nil},
+		{"/dir/up", "/docs/a.txt", nil},
+		{"/dir/../a.txt", "/docs/a.txt", nil},
+		{"/chain", "/docs/a.txt", nil},
+		{fmt.Sprintf("/c%d-1", MaxSymlinkDepth), "/docs/a.txt", nil},
+		{fmt.Sprintf("/c%d-1", MaxSymlinkDepth+1), "", ErrSymlinkLoop},
+		{"/self", "", ErrSymlinkLoop},
+		{"/loopA", "", ErrSymlinkLoop},
+		{"/loopB/x", "", ErrSymlinkLoop},
+		{"/dangling", "", ErrItemNotFound},
+		{"/dangling/x", "", ErrItemNotFound},
+		{"/empty", "", ErrItemNotFound},
+		{"/empty/a.txt", "", ErrItemNotFound},
+		{"/abs/x", "", ErrNotDirectory},
+	}
+	for _, tt := range tests {
+		item, err := vfs.FindItem(tt.path)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("FindItem(%q): got error %v, want %v", tt.path, err, tt.err)
+			continue
+		}
+		if err == nil && item.Path() != tt.want {
+			t.Errorf("FindItem(%q) found %s, want %s", tt.path, item.Path(), tt.want)
+		}
+	}
+
+	// bez rozwijania dowiązanie jest zwracane samo, nawet wiszące lub puste
+	for path, target := range map[string]string{"/dangling": "/missing", "/empty": "", "/self": "self", "/docs/sub/up": "../a.txt"} {
+		item, err := vfs.FindItemNoFollow(path)
+		if _, isLink := item.(*SymLink); err != nil || !isLink {
+			t.Errorf("FindItemNoFollow(%q) = %v, %v; want the symlink", path, item, err)
+		}
+		if got, err := vfs.Readlink(path); err != nil || got != target {
+			t.Errorf("Readlink(%q) = %q, %v; want %q", path, got, err, target)
+		}
+	}
+	if _, err := vfs.Readlink("/docs/a.txt"); !errors.Is(err, ErrNotSymlink) {
+		t.Errorf("Readlink of a file: got error %v, want %v", err, ErrNotSymlink)
+	}
+
+	// wiszące dowiązanie zaczyna działać po utworzeniu celu
+	if err := vfs.CreateFile("/", "missing", nil); err != nil {
+		t.Fatal(err)
+	}
+	if item, err := vfs.FindItem("/dangling"); err != nil || item.Path() != "/missing" {
+		t.Errorf("FindItem(/dangling) after creating the target = %v, %v", item, err)
+	}
+}
+
+func links(t *testing.T, vfs *VirtualFileSystem, path string) int {
+	t.Helper()
+	item, err := vfs.FindItem(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, ok := item.(*Plik)
+	if !ok {
+		t.Fatalf("%s is %T, want *Plik", path, item)
+	}
+	return file.Links()
+}
+
+// TestHardLinkCounts sprawdza licznik dowiązań twardych przy tworzeniu,
+// usuwaniu, przenoszeniu, kopiowaniu i nadpisywaniu
+func TestHardLinkCounts(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	for _, err := range []error{
+		vfs.CreateFile("/", "f", []byte("data")),
+		vfs.CreateFolder("/", "dir"),
+		vfs.CreateSymlink("/", "link", "/f"),
+		vfs.CreateFile("/", "other", []byte("other")),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	step := func(name string, err error, path string, want int) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := links(t, vfs, path); got != want {
+			t.Errorf("%s: %s has %d links, want %d", name, path, got, want)
+		}
+	}
+
+	step("create", nil, "/f", 1)
+	step("hard link", vfs.CreateHardLink("/", "h1", "/f"), "/f", 2)
+	step("hard link in a folder", vfs.CreateHardLink("/dir", "h2", "/h1"), "/f", 3)
+	step("hard link through symlink", vfs.CreateHardLink("/", "h3", "/link"), "/f", 4)
+	if err := vfs.CreateHardLink("/", "h1", "/f"); !errors.Is(err, ErrItemExists) {
+		t.Errorf("hard link to an existing name: got error %v, want %v", err, ErrItemExists)
+	}
+	step("failed hard link", nil, "/f", 4)
+	if err := vfs.CreateHardLink("/", "d", "/dir"); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("hard link to a folder: got error %v, want %v", err, ErrIsDirectory)
+	}
+
+	step("move", vfs.Move("/h3", "/dir/h3", false), "/dir/h3", 4)
+	step("copy", vfs.Copy("/h1", "/copy", false, false), "/copy", 1)
+	step("copy leaves the original", nil, "/f", 4)
+	step("delete original", vfs.DeleteItem("/f"), "/h1", 3)
+	if data, err := vfs.ReadFile("h1"); err != nil || string(data) != "data" {
+		t.Errorf("ReadFile(h1) after deleting /f = %q, %v; want %q", data, err, "data")
+	}
+	step("overwrite by move", vfs.Move("/other", "/h1", true), "/dir/h2", 2)
+	step("delete folder", vfs.DeleteItem("/dir"), "/copy", 1)
+
+	// dowiązanie symboliczne wskazuje usuniętą nazwę, a nie dane
+	if _, err := vfs.FindItem("/link"); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("symlink to deleted file: got error %v, want %v", err, ErrItemNotFound)
+	}
+}
+
+// Zapis przez jedno dowiązanie twarde jest widoczny przez pozostałe
+func TestHardLinkSharesData(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	if err := vfs.CreateFile("/", "f", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := vfs.CreateHardLink("/", "h", "/f"); err != nil {
+		t.Fatal(err)
+	}
+	h, err := vfs.OpenFile("/h", os.O_WRONLY|os.O_APPEND)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write([]byte("!"))
+	h.Close()
+	if data, err := vfs.ReadFile("f"); err != nil || string(data) != "data!" {
+		t.Errorf("ReadFile(f) = %q, %v; want %q", data, err, "data!")
+	}
+}
+
+// moveTree buduje drzewo do testów przenoszenia, kopiowania i zmiany nazwy
+func moveTree(t *testing.T) *VirtualFileSystem {
+	t.Helper()
+	vfs := NewVirtualFileSystem()
+	for _, err := range []error{
+		vfs.CreateFolder("/", "a"),
+		vfs.CreateFolder("/a", "b"),
+		vfs.CreateFile("/a/b", "c.txt", []byte("C")),
+		vfs.CreateFile("/a", "f.txt", []byte("F")),
+		vfs.CreateSymlink("/a", "link", "b/c.txt"),
+		vfs.CreateFolder("/", "x"),
+		vfs.CreateFile("/x", "f.txt", []byte("X")),
+		vfs.CreateFolder("/x", "empty"),
+		vfs.CreateFolder("/x", "full"),
+		vfs.CreateFile("/x/full", "z", nil),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return vfs
+}
+
+// checkPaths sprawdza, że każdy element jest dostępny pod swoją ścieżką
+// i ma zapisaną tę samą ścieżkę i nazwę
+func checkPaths(t *testing.T, vfs *VirtualFileSystem, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		item, err := vfs.FindItemNoFollow(path)
+		if err != nil {
+			t.Errorf("FindItemNoFollow(%q): %v", path, err)
+			continue
+		}
+		want := path
+		if _, isDir := item.(*Katalog); isDir {
+			want += "/"
+		}
+		if item.Path() != want {
+			t.Errorf("%s has stored path %s", path, item.Path())
+		}
+		if name := path[strings.LastIndex(path, "/")+1:]; item.Name() != name {
+			t.Errorf("%s has name %s", path, item.Name())
+		}
+	}
+}
+
+func checkMissing(t *testing.T, vfs *VirtualFileSystem, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if _, err := vfs.FindItemNoFollow(path); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("FindItemNoFollow(%q): got error %v, want %v", path, err, ErrItemNotFound)
+		}
+	}
+}
+
+func TestMoveFixesDescendantPaths(t *testing.T) {
+	vfs := moveTree(t)
+	if err := vfs.Move("/a", "/x/moved", false); err != nil {
+		t.Fatal(err)
+	}
+	checkPaths(t, vfs, "/x/moved", "/x/moved/b", "/x/moved/b/c.txt", "/x/moved/f.txt", "/x/moved/link")
+	checkMissing(t, vfs, "/a", "/a/b/c.txt")
+	// względne dowiązanie przenosi się razem z celem
+	if item, err := vfs.FindItem("/x/moved/link"); err != nil || item.Path() != "/x/moved/b/c.txt" {
+		t.Errorf("FindItem(/x/moved/link) = %v, %v", item, err)
+	}
+
+	// kolejne przeniesienie poprawia ścieżki już przeniesionych potomków
+	if err := vfs.Move("/x/moved/b", "/b2", false); err != nil {
+		t.Fatal(err)
+	}
+	checkPaths(t, vfs, "/b2", "/b2/c.txt")
+	if err := vfs.Rename("/x", "y", false); err != nil {
+		t.Fatal(err)
+	}
+	checkPaths(t, vfs, "/y", "/y/f.txt", "/y/moved", "/y/moved/f.txt", "/y/full/z")
+}
+
+func TestMoveErrors(t *testing.T) {
+	tests := []struct {
+		src, dst  string
+		overwrite bool
+		want      error
+	}{
+		{"/a", "/a/inside", false, ErrInvalidMove},
+		{"/a", "/a/b/inside", true, ErrInvalidMove},
+		{"/a/", "/x/../a/b/../inside", false, ErrInvalidMove},
+		{"/", "/x/root", false, ErrPermissionDenied},
+		{"/missing", "/x/m", false, ErrItemNotFound},
+		{"/a/f.txt", "/missing/f.txt", false, ErrItemNotFound},
+		{"/a/f.txt", "/x/f.txt/g", false, ErrNotDirectory},
+		{"/a/f.txt", "/x/..", false, ErrInvalidName},
+		// konflikt nazw bez nadpisywania
+		{"/a/f.txt", "/x/f.txt", false, ErrItemExists},
+		{"/a/b", "/x/empty", false, ErrItemExists},
+		// przy nadpisywaniu rodzaj elementów musi się zgadzać
+		{"/a/f.txt", "/x/empty", true, ErrIsDirectory},
+		{"/a/b", "/x/f.txt", true, ErrNotDirectory},
+		{"/a/b", "/x/full", true, ErrItemExists},
+	}
+	for _, tt := range tests {
+		vfs := moveTree(t)
+		if err := vfs.Move(tt.src, tt.dst, tt.overwrite); !errors.Is(err, tt.want) {
+			t.Errorf("Move(%q, %q, %v): got error %v, want %v", tt.src, tt.dst, tt.overwrite, err, tt.want)
+		}
+		// nieudane przeniesienie niczego nie zmienia
+		checkPaths(t, vfs, "/a/b/c.txt", "/a/f.txt", "/x/f.txt", "/x/empty", "/x/full/z")
+	}
+}
+
+func TestMoveOverwrite(t *testing.T) {
+	vfs := moveTree(t)
+	if err := vfs.Move("/a/f.txt", "/x/f.txt", true); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := vfs.ReadFile("x/f.txt"); err != nil || string(data) != "F" {
+		t.Errorf("ReadFile(x/f.txt) = %q, %v; want %q", data, err, "F")
+	}
+	checkMissing(t, vfs, "/a/f.txt")
+
+	// pusty katalog może zostać zastąpiony katalogiem
+	if err := vfs.Move("/a/b", "/x/empty", true); err != nil {
+		t.Fatal(err)
+	}
+	checkPaths(t, vfs, "/x/empty", "/x/empty/c.txt")
+
+	// przeniesienie na samego siebie niczego nie zmienia
+	if err := vfs.Move("/x/f.txt", "/x/f.txt", false); err != nil {
+		t.Errorf("Move onto itself: %v", err)
+	}
+	checkPaths(t, vfs, "/x/f.txt")
+}
+
+func TestRename(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "a/b", "/"} {
+		vfs := moveTree(t)
+		if err := vfs.Rename("/a/f.txt", name, false); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Rename to %q: got error %v, want %v", name, err, ErrInvalidName)
+		}
+		checkPaths(t, vfs, "/a/f.txt")
+	}
+
+	vfs := moveTree(t)
+	if err := vfs.Rename("/a/f.txt", "b", false); !errors.Is(err, ErrItemExists) {
+		t.Errorf("Rename to an existing name: got error %v, want %v", err, ErrItemExists)
+	}
+	if err := vfs.Rename("/a/missing", "m", false); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("Rename of a missing item: got error %v, want %v", err, ErrItemNotFound)
+	}
+	// zmiana nazwy dowiązania nie zmienia jego celu
+	if err := vfs.Rename("/a/link", "renamed", false); err != nil {
+		t.Fatal(err)
+	}
+	checkPaths(t, vfs, "/a/renamed", "/a/b/c.txt")
+	if target, err := vfs.Readlink("/a/renamed"); err != nil || target != "b/c.txt" {
+		t.Errorf("Readlink(/a/renamed) = %q, %v", target, err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	vfs := moveTree(t)
+	if err := vfs.Copy("/a", "/x/copy", false, false); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("non-recursive Copy of a folder: got error %v, want %v", err, ErrIsDirectory)
+	}
+	if err := vfs.Copy("/a", "/a/b/copy", true, false); !errors.Is(err, ErrInvalidMove) {
+		t.Errorf("Copy into itself: got error %v, want %v", err, ErrInvalidMove)
+	}
+	if err := vfs.Copy("/a/f.txt", "/a/f.txt", false, true); !errors.Is(err, ErrItemExists) {
+		t.Errorf("Copy onto itself: got error %v, want %v", err, ErrItemExists)
+	}
+	if err := vfs.Copy("/a/f.txt", "/x/f.txt", false, false); !errors.Is(err, ErrItemExists) {
+		t.Errorf("Copy onto an existing file: got error %v, want %v", err, ErrItemExists)
+	}
+	if err := vfs.Copy("/a/f.txt", "/x/f.txt", false, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := vfs.ReadFile("x/f.txt"); err != nil || string(data) != "F" {
+		t.Errorf("ReadFile(x/f.txt) after overwrite = %q, %v", data, err)
+	}
+
+	if err := vfs.Copy("/a", "/x/copy", true, false); err != nil {
+		t.Fatal(err)
+	}
+	checkPaths(t, vfs, "/a/b/c.txt", "/a/link", "/x/copy", "/x/copy/b", "/x/copy/b/c.txt", "/x/copy/f.txt", "/x/copy/link")
+	// dowiązanie w kopii wskazuje kopię celu
+	if item, err := vfs.FindItem("/x/copy/link"); err != nil || item.Path() != "/x/copy/b/c.txt" {
+		t.Errorf("FindItem(/x/copy/link) = %v, %v", item, err)
+	}
+
+	// kopia jest głęboka: zapis do kopii nie zmienia oryginału i odwrotnie
+	for path, data := range map[string]string{"/x/copy/b/c.txt": "copy", "/a/f.txt": "original"} {
+		h, err := vfs.OpenFile(path, os.O_WRONLY|os.O_TRUNC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write([]byte(data))
+		h.Close()
+	}
+	for name, want := range map[string]string{"a/b/c.txt": "C", "x/copy/b/c.txt": "copy", "a/f.txt": "original", "x/copy/f.txt": "F", "x/f.txt": "F"} {
+		if data, err := vfs.ReadFile(name); err != nil || string(data) != want {
+			t.Errorf("ReadFile(%s) = %q, %v; want %q", name, data, err, want)
+		}
+	}
+	if err := vfs.CreateFile("/x/copy/b", "new", nil); err != nil {
+		t.Fatal(err)
+	}
+	checkMissing(t, vfs, "/a/b/new")
+}
+
+// TestOpenFileCreateThroughDanglingSymlink sprawdza, że os.O_CREATE na
+// wiszącym dowiązaniu tworzy plik pod jego celem
+func TestOpenFileCreateThroughDanglingSymlink(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	for _, err := range []error{
+		vfs.CreateFolder("/", "d"),
+		vfs.CreateSymlink("/", "abs", "/d/abs.txt"),
+		vfs.CreateSymlink("/d", "rel", "../rel.txt"),
+		vfs.CreateSymlink("/", "chain", "d/rel"),
+		vfs.CreateSymlink("/", "nodir", "/missing/x"),
+		vfs.CreateSymlink("/", "empty", ""),
+		vfs.CreateSymlink("/", "slash", "/d/new/"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path string
+		flag int
+		want string // ścieżka utworzonego pliku
+		err  error
+	}{
+		{"/abs", os.O_WRONLY | os.O_CREATE, "/d/abs.txt", nil},
+		{"/d/rel", os.O_RDWR | os.O_CREATE, "/rel.txt", nil},
+		{"/chain", os.O_WRONLY | os.O_CREATE, "/rel.txt", nil},
+		{"/abs", os.O_WRONLY | os.O_CREATE | os.O_EXCL, "", ErrItemExists},
+		{"/nodir", os.O_WRONLY | os.O_CREATE, "", ErrItemNotFound},
+		{"/empty", os.O_WRONLY | os.O_CREATE, "", ErrItemNotFound},
+		{"/slash", os.O_WRONLY | os.O_CREATE, "", ErrIsDirectory},
+	}
+	for _, tt := range tests {
+		h, err := vfs.OpenFile(tt.path, tt.flag)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("OpenFile(%q, %#x): got error %v, want %v", tt.path, tt.flag, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if h.Name() != tt.want {
+			t.Errorf("OpenFile(%q) opened %s, want %s", tt.path, h.Name(), tt.want)
+		}
+		h.Write([]byte(tt.path))
+		h.Close()
+	}
+	// plik utworzony przez dowiązanie jest zwykłym plikiem pod celem
+	if data, err := vfs.ReadFile("rel.txt"); err != nil || string(data) != "/chain" {
+		t.Errorf("ReadFile(rel.txt) = %q, %v; want %q", data, err, "/chain")
+	}
+	if _, err := vfs.Readlink("/abs"); err != nil {
+		t.Errorf("Readlink(/abs) after create: %v", err)
+	}
+}
+
+func TestFileHandle(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	if err := vfs.CreateFile("/", "f", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	// odczyt kończy się io.EOF dopiero po danych
+	h, err := vfs.OpenFile("/f", os.O_RDONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 3)
+	for _, want := range []struct {
+		data string
+		err  error
+	}{{"hel", nil}, {"lo", nil}, {"", io.EOF}, {"", io.EOF}} {
+		n, err := h.Read(buf)
+		if string(buf[:n]) != want.data || err != want.err {
+			t.Errorf("Read = %q, %v; want %q, %v", buf[:n], err, want.data, want.err)
+		}
+	}
+	if n, err := h.ReadAt(buf, 3); n != 2 || err != io.EOF {
+		t.Errorf("ReadAt past the end = %d, %v; want 2, EOF", n, err)
+	}
+	if n, err := h.ReadAt(buf, 1); n != 3 || err != nil {
+		t.Errorf("ReadAt(1) = %d, %v; want 3, nil", n, err)
+	}
+	if _, err := h.Write([]byte("x")); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Write on O_RDONLY: got error %v, want %v", err, ErrPermissionDenied)
+	}
+
+	// Seek ze wszystkimi wartościami whence
+	seeks := []struct {
+		offset int64
+		whence int
+		pos    int64
+		err    error
+	}{
+		{1, io.SeekStart, 1, nil},
+		{2, io.SeekCurrent, 3, nil},
+		{-1, io.SeekEnd, 4, nil},
+		{10, io.SeekEnd, 15, nil},
+		{-1, io.SeekStart, 15, ErrInvalidOffset},
+		{-16, io.SeekCurrent, 15, ErrInvalidOffset},
+		{-6, io.SeekEnd, 15, ErrInvalidOffset},
+		{0, 3, 15, ErrInvalidOffset},
+	}
+	for _, tt := range seeks {
+		pos, err := h.Seek(tt.offset, tt.whence)
+		if !errors.Is(err, tt.err) || (err == nil && pos != tt.pos) {
+			t.Errorf("Seek(%d, %d) = %d, %v; want %d, %v", tt.offset, tt.whence, pos, err, tt.pos, tt.err)
+		}
+		// nieudany Seek nie zmienia pozycji
+		if cur, _ := h.Seek(0, io.SeekCurrent); cur != tt.pos {
+			t.Errorf("after Seek(%d, %d) offset is %d, want %d", tt.offset, tt.whence, cur, tt.pos)
+		}
+	}
+	if n, err := h.Read(buf); n != 0 || err != io.EOF {
+		t.Errorf("Read past the end = %d, %v; want 0, EOF", n, err)
+	}
+	if _, err := h.ReadAt(buf, -1); !errors.Is(err, ErrInvalidOffset) {
+		t.Errorf("ReadAt(-1): got error %v, want %v", err, ErrInvalidOffset)
+	}
+	h.Close()
+	if _, err := h.Read(buf); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Read after Close: got error %v, want %v", err, os.ErrClosed)
+	}
+	if err := h.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("second Close: got error %v, want %v", err, os.ErrClosed)
+	}
+
+	// O_APPEND zapisuje zawsze na końcu, niezależnie od Seek
+	h, err = vfs.OpenFile("/f", os.O_RDWR|os.O_APPEND)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Seek(0, io.SeekStart)
+	if _, err := h.Write([]byte(" world")); err != nil {
+		t.Fatal(err)
+	}
+	if pos, _ := h.Seek(0, io.SeekCurrent); pos != 11 {
+		t.Errorf("offset after append is %d, want 11", pos)
+	}
+	if _, err := h.WriteAt([]byte("x"), 0); !errors.Is(err, ErrAppendWriteAt) {
+		t.Errorf("WriteAt with O_APPEND: got error %v, want %v", err, ErrAppendWriteAt)
+	}
+	h.Close()
+
+	// zapis za końcem pliku wypełnia lukę zerami, a WriteAt nie przesuwa pozycji
+	h, err = vfs.OpenFile("/f", os.O_WRONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.WriteAt([]byte("H"), 0); err != nil {
+		t.Fatal(err)
+	}
+	h.Seek(13, io.SeekStart)
+	h.Write([]byte("!"))
+	if _, err := h.Read(buf); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Read on O_WRONLY: got error %v, want %v", err, ErrPermissionDenied)
+	}
+	h.Close()
+	if data, _ := vfs.ReadFile("f"); string(data) != "Hello world\x00\x00!" {
+		t.Errorf("ReadFile(f) = %q, want %q", data, "Hello world\x00\x00!")
+	}
+
+	// O_TRUNC obcina plik tylko przy otwarciu do zapisu
+	h, err = vfs.OpenFile("/f", os.O_RDONLY|os.O_TRUNC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+	if data, _ := vfs.ReadFile("f"); len(data) != 14 {
+		t.Errorf("O_RDONLY|O_TRUNC truncated the file to %q", data)
+	}
+	h, err = vfs.OpenFile("/f", os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+	if data, _ := vfs.ReadFile("f"); len(data) != 0 {
+		t.Errorf("O_WRONLY|O_TRUNC left %q", data)
+	}
+
+	// O_EXCL wymaga, żeby plik nie istniał
+	if _, err := vfs.OpenFile("/f", os.O_WRONLY|os.O_CREATE|os.O_EXCL); !errors.Is(err, ErrItemExists) {
+		t.Errorf("O_EXCL on an existing file: got error %v, want %v", err, ErrItemExists)
+	}
+	if _, err := vfs.OpenFile("/g", os.O_WRONLY); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("open of a missing file without O_CREATE: got error %v, want %v", err, ErrItemNotFound)
+	}
+	h, err = vfs.OpenFile("/g", os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+	if _, err := vfs.OpenFile("/", os.O_RDONLY); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("open of a folder: got error %v, want %v", err, ErrIsDirectory)
+	}
+}
+
+func TestReadOnlyFileHandle(t *testing.T) {
+	vfs := NewVirtualFileSystem()
+	if err := vfs.CreateReadOnlyFile("/", "ro", []byte("fixed")); err != nil {
+		t.Fatal(err)
+	}
+	for _, flag := range []int{os.O_WRONLY, os.O_RDWR, os.O_RDWR | os.O_APPEND, os.O_RDONLY | os.O_TRUNC, os.O_WRONLY | os.O_CREATE} {
+		if _, err := vfs.OpenFile("/ro", flag); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("OpenFile(/ro, %#x): got error %v, want %v", flag, err, ErrPermissionDenied)
+		}
+	}
+	h, err := vfs.OpenFile("/ro", os.O_RDONLY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	if _, err := h.Write([]byte("x")); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Write: got error %v, want %v", err, ErrPermissionDenied)
+	}
+	if data, err := io.ReadAll(h); err != nil || string(data) != "fixed" {
+		t.Errorf("ReadAll = %q, %v; want %q", data, err, "fixed")
+	}
+}
@@ -0,0 +1,404 @@
+// Package delimited wczytuje pliki CSV i podobne (separator ';', ',' lub
+// tabulator) do struktur, dopasowując kolumny nagłówka do pól po tagach:
+//
+//	type Row struct {
+//		Date  time.Time `csv:"Date,parse=usdate"`
+//		Close float64   `csv:"Close/Last,parse=dollars"`
+//		Note  string    `csv:"Note,optional"`
+//	}
+//
+// Pole bez tagu jest dopasowywane po swojej nazwie, a pole z tagiem "-"
+// jest pomijane. Wielkość liter w nazwach kolumn nie ma znaczenia.
+package delimited
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Domyślny format pól time.Time bez parsera w tagu
+const defaultTimeLayout = "2006-01-02"
+
+var (
+	ErrColumnCount     = errors.New("niepoprawna liczba kolumn")
+	ErrMissingColumn   = errors.New("brak kolumny w nagłówku")
+	ErrUnknownParser   = errors.New("nieznany parser")
+	ErrUnsupportedType = errors.New("nieobsługiwany typ pola")
+)
+
+// ParseFunc zamienia tekst pola na wartość przypisywaną do pola struktury;
+// typ zwracanej wartości musi dać się przypisać do typu pola
+type ParseFunc func(string) (any, error)
+
+// Parsers to parsery dostępne w tagach bez rejestrowania ich w Options
+var Parsers = map[string]ParseFunc{
+	"dollars": func(s string) (any, error) { return ParseDollars(s) },
+	"usdate":  func(s string) (any, error) { return ParseUSDate(s) },
+}
+
+// ParseDollars odczytuje kwotę w postaci "$134.29", "-$1,024.50" albo "134.29"
+func ParseDollars(s string) (float64, error) {
+	clean := strings.TrimSpace(s)
+	negative := strings.HasPrefix(clean, "-")
+	clean = strings.TrimPrefix(clean, "-")
+	clean = strings.ReplaceAll(strings.TrimPrefix(clean, "$"), ",", "")
+	value, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("niepoprawna kwota %q", s)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// ParseUSDate odczytuje datę w formacie MM/DD/YYYY
+func ParseUSDate(s string) (time.Time, error) {
+	return time.Parse("01/02/2006", strings.TrimSpace(s))
+}
+
+// Options konfiguruje Reader
+type Options struct {
+	Comma   rune                 // 0 - wykrywany z nagłówka (';', ',' albo tabulator)
+	MaxRows int                  // 0 - bez limitu
+	Parsers map[string]ParseFunc // parsery dostępne w tagach obok Parsers
+}
+
+// RowError opisuje wiersz pliku, którego nie udało się odczytać
+type RowError struct {
+	Line   int
+	Column string // nazwa kolumny; puste, gdy błąd dotyczy całego wiersza
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("linia %d, kolumna %q: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("linia %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// RowErrors zbiera wszystkie błędne wiersze znalezione przez ReadAll
+type RowErrors struct {
+	Rows []*RowError
+}
+
+func (e *RowErrors) Error() string {
+	lines := make([]string, len(e.Rows))
+	for i, row := range e.Rows {
+		lines[i] = row.Error()
+	}
+	return fmt.Sprintf("%d błędnych wierszy: %s", len(e.Rows), strings.Join(lines, "; "))
+}
+
+// boundField wiąże pole struktury z kolumną pliku
+type boundField struct {
+	index  []int // indeks pola dla reflect.Value.FieldByIndex
+	column int   // numer kolumny w wierszu
+	name   string
+	parse  ParseFunc // nil - konwersja według typu pola
+	layout string    // format dla pól time.Time bez parsera
+}
+
+// Reader czyta plik wiersz po wierszu, wypełniając struktury typu T
+type Reader[T any] struct {
+	reader  *csv.Reader
+	fields  []boundField
+	columns int
+	rows    int
+	maxRows int
+	comma   rune
+}
+
+// NewReader pomija znacznik BOM, ustala separator, wczytuje nagłówek
+// i dopasowuje jego kolumny do pól typu T
+func NewReader[T any](r io.Reader, opts Options) (*Reader[T], error) {
+	buffered := bufio.NewReader(r)
+	if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+
+	comma := opts.Comma
+	if comma == 0 {
+		comma = DetectComma(buffered)
+	}
+	reader := csv.NewReader(buffered)
+	reader.Comma = comma
+	// liczbę kolumn sprawdzamy sami, żeby zgłosić numer linii
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("nie udało się odczytać nagłówka: %w", err)
+	}
+	fields, err := bindFields(reflect.TypeFor[T](), header, opts.Parsers)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader[T]{reader: reader, fields: fields, columns: len(header), maxRows: opts.MaxRows, comma: comma}, nil
+}
+
+// Comma zwraca separator użyty do czytania pliku
+func (dr *Reader[T]) Comma() rune { return dr.comma }
+
+// DetectComma wybiera separator, który najczęściej występuje w pierwszej
+// linii poza cudzysłowami
+func DetectComma(r *bufio.Reader) rune {
+	line, _ := r.Peek(r.Size())
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	counts := map[rune]int{}
+	quoted := false
+	for _, c := range string(line) {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case !quoted && (c == ';' || c == ',' || c == '\t'):
+			counts[c]++
+		}
+	}
+	best := ','
+	for _, candidate := range []rune{';', '\t'} {
+		if counts[candidate] > counts[best] {
+			best = candidate
+		}
+	}
+	return best
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// bindFields dopasowuje pola struktury do kolumn nagłówka
+func bindFields(t reflect.Type, header []string, parsers map[string]ParseFunc) ([]boundField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s nie jest strukturą", ErrUnsupportedType, t)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var fields []boundField
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		tag := sf.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+		field := boundField{index: sf.Index, column: -1, name: name, layout: defaultTimeLayout}
+		optional := false
+		for _, option := range strings.Split(options, ",") {
+			key, value, _ := strings.Cut(option, "=")
+			switch key {
+			case "":
+			case "optional":
+				optional = true
+			case "parse":
+				parse, ok := parsers[value]
+				if !ok {
+					parse, ok = Parsers[value]
+				}
+				if !ok {
+					return nil, fmt.Errorf("%w %q w polu %s", ErrUnknownParser, value, sf.Name)
+				}
+				field.parse = parse
+			case "layout":
+				field.layout = value
+			default:
+				return nil, fmt.Errorf("nieznana opcja tagu %q w polu %s", key, sf.Name)
+			}
+		}
+		if field.parse == nil && !convertible(sf.Type) {
+			return nil, fmt.Errorf("%w: %s (pole %s)", ErrUnsupportedType, sf.Type, sf.Name)
+		}
+
+		for i, column := range header {
+			if strings.EqualFold(column, name) {
+				field.column = i
+				break
+			}
+		}
+		if field.column < 0 {
+			if optional {
+				continue
+			}
+			return nil, &RowError{Line: 1, Column: name, Err: ErrMissingColumn}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// convertible sprawdza, czy typ pola da się wypełnić bez własnego parsera
+func convertible(t reflect.Type) bool {
+	if t == timeType || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// Read zwraca kolejny rekord, io.EOF na końcu pliku (lub po MaxRows
+// rekordach) albo *RowError dla błędnego wiersza, po którym można czytać dalej
+func (dr *Reader[T]) Read() (T, error) {
+	var item T
+	if dr.maxRows > 0 && dr.rows >= dr.maxRows {
+		return item, io.EOF
+	}
+	record, err := dr.reader.Read()
+	if err == io.EOF {
+		return item, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		dr.rows++
+		return item, &RowError{Line: parseErr.StartLine, Err: parseErr.Err}
+	}
+	if err != nil {
+		return item, err
+	}
+	dr.rows++
+
+	line, _ := dr.reader.FieldPos(0)
+	if len(record) != dr.columns {
+		return item, &RowError{Line: line, Err: fmt.Errorf("%w: %d zamiast %d", ErrColumnCount, len(record), dr.columns)}
+	}
+	value := reflect.ValueOf(&item).Elem()
+	for _, field := range dr.fields {
+		if err := field.set(value.FieldByIndex(field.index), record[field.column]); err != nil {
+			return item, &RowError{Line: line, Column: field.name, Err: err}
+		}
+	}
+	return item, nil
+}
+
+// set zapisuje tekst pola do wartości pola struktury
+func (f boundField) set(v reflect.Value, s string) error {
+	if f.parse != nil {
+		parsed, err := f.parse(s)
+		if err != nil {
+			return err
+		}
+		pv := reflect.ValueOf(parsed)
+		if !pv.IsValid() || !pv.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("%w: parser zwrócił %T zamiast %s", ErrUnsupportedType, parsed, v.Type())
+		}
+		v.Set(pv)
+		return nil
+	}
+
+	if v.Type() == timeType {
+		t, err := time.Parse(f.layout, strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("niepoprawna wartość logiczna %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("niepoprawna liczba całkowita %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("niepoprawna liczba naturalna %q", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("niepoprawna liczba %q", s)
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
+
+// ReadAll wczytuje wszystkie rekordy z r. Poprawne rekordy są zwracane
+// zawsze; błędne wiersze są zgłaszane razem jako *RowErrors.
+func ReadAll[T any](r io.Reader, opts Options) ([]T, error) {
+	dr, err := NewReader[T](r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	var malformed []*RowError
+	for {
+		item, err := dr.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			malformed = append(malformed, rowErr)
+			continue
+		}
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+
+	if len(malformed) > 0 {
+		return items, &RowErrors{Rows: malformed}
+	}
+	return items, nil
+}
+
+// ReadFile otwiera plik i wczytuje go przez ReadAll
+func ReadFile[T any](path string, opts Options) ([]T, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("nie udało się otworzyć pliku %s: %w", path, err)
+	}
+	defer file.Close()
+
+	return ReadAll[T](file, opts)
+}
@@ -0,0 +1,268 @@
+package delimited
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type quote struct {
+	Date   time.Time `csv:"Date,parse=usdate"`
+	Close  float64   `csv:"Close/Last,parse=dollars"`
+	Volume int64
+	Note   string `csv:"Note,optional"`
+	Skip   string `csv:"-"`
+	hidden string
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestReadAll(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"comma", "Date,Close/Last,Volume\n10/04/2024,$124.92,244465600\n10/03/2024,\"$1,122.85\",277118000\n"},
+		{"semicolon", "Date;Close/Last;Volume\n10/04/2024;$124.92;244465600\n10/03/2024;$1,122.85;277118000\n"},
+		{"tab and CRLF", "Date\tClose/Last\tVolume\r\n10/04/2024\t$124.92\t244465600\r\n10/03/2024\t$1,122.85\t277118000\r\n"},
+		{"BOM and header case", "\ufeffdate , CLOSE/LAST,volume\n10/04/2024,$124.92,244465600\n10/03/2024,\"$1,122.85\",277118000\n"},
+	}
+	want := []quote{
+		{Date: date(2024, 10, 4), Close: 124.92, Volume: 244465600},
+		{Date: date(2024, 10, 3), Close: 1122.85, Volume: 277118000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadAll[quote](strings.NewReader(tt.input), Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %d rows, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if !got[i].Date.Equal(want[i].Date) || got[i].Close != want[i].Close || got[i].Volume != want[i].Volume {
+					t.Errorf("row %d: got %+v, want %+v", i+1, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectComma(t *testing.T) {
+	tests := []struct {
+		line  string
+		comma rune
+	}{
+		{"a,b,c\n1;2;3;4;5\n", ','},
+		{"a;b;c", ';'},
+		{"a\tb\tc\n", '\t'},
+		{"\"a;b;c\",d,e\n", ','},
+		{"\"x, y\";z\n", ';'},
+		{"single\n", ','},
+		{"", ','},
+		// przy remisie wygrywa przecinek
+		{"a;b,c\n", ','},
+	}
+	for _, tt := range tests {
+		r := bufio.NewReader(strings.NewReader(tt.line))
+		if got := DetectComma(r); got != tt.comma {
+			t.Errorf("DetectComma(%q) = %q, want %q", tt.line, got, tt.comma)
+		}
+		// wykrywanie nie może zużyć danych
+		if rest, _ := io.ReadAll(r); string(rest) != tt.line {
+			t.Errorf("DetectComma(%q) consumed input, %q left", tt.line, rest)
+		}
+	}
+
+	reader, err := NewReader[quote](strings.NewReader("Date|Close/Last|Volume\n"), Options{Comma: '|'})
+	if err != nil || reader.Comma() != '|' {
+		t.Errorf("explicit comma: %v, %v", reader, err)
+	}
+}
+
+func TestParsers(t *testing.T) {
+	dollars := []struct {
+		text  string
+		value float64
+		ok    bool
+	}{
+		{"$134.29", 134.29, true},
+		{" 134.29 ", 134.29, true},
+		{"-$1,024.50", -1024.5, true},
+		{"$0", 0, true},
+		{"$", 0, false},
+		{"12 USD", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range dollars {
+		value, err := ParseDollars(tt.text)
+		if (err == nil) != tt.ok || value != tt.value {
+			t.Errorf("ParseDollars(%q) = %v, %v; want %v, ok %v", tt.text, value, err, tt.value, tt.ok)
+		}
+	}
+
+	dates := []struct {
+		text string
+		date time.Time
+		ok   bool
+	}{
+		{"10/04/2024", date(2024, 10, 4), true},
+		{" 02/29/2024", date(2024, 2, 29), true},
+		{"02/29/2023", time.Time{}, false},
+		{"2024-10-04", time.Time{}, false},
+		{"13/01/2024", time.Time{}, false},
+	}
+	for _, tt := range dates {
+		value, err := ParseUSDate(tt.text)
+		if (err == nil) != tt.ok || !value.Equal(tt.date) {
+			t.Errorf("ParseUSDate(%q) = %v, %v; want %v, ok %v", tt.text, value, err, tt.date, tt.ok)
+		}
+	}
+}
+
+func TestRowErrors(t *testing.T) {
+	input := "Date,Close/Last,Volume,Note\n" +
+		"10/04/2024,$124.92,1,ok\n" + // linia 2
+		"10/03/2024,$124.92,1\n" + // linia 3: brak kolumny
+		"10/02/2024,$124.92,1,a,b\n" + // linia 4: nadmiarowa kolumna
+		"10/01/2024,\"multi\nline\",1,x\n" + // linie 5-6: zła kwota
+		"2024-09-30,$1,1,x\n" + // linia 7: zła data
+		"09/27/2024,$1,many,x\n" + // linia 8: zła liczba
+		"09/26/2024,$2,2,\"unterminated\n" // linia 9: błąd składni CSV
+	items, err := ReadAll[quote](strings.NewReader(input), Options{})
+	if len(items) != 1 || items[0].Note != "ok" {
+		t.Errorf("got %+v, want only the row from line 2", items)
+	}
+	var rowErrs *RowErrors
+	if !errors.As(err, &rowErrs) {
+		t.Fatalf("got %v, want *RowErrors", err)
+	}
+
+	want := []struct {
+		line   int
+		column string
+		err    error
+	}{
+		{3, "", ErrColumnCount},
+		{4, "", ErrColumnCount},
+		{5, "Close/Last", nil},
+		{7, "Date", nil},
+		{8, "Volume", nil},
+		{9, "", nil},
+	}
+	if len(rowErrs.Rows) != len(want) {
+		t.Fatalf("got %d row errors (%v), want %d", len(rowErrs.Rows), err, len(want))
+	}
+	for i, tt := range want {
+		row := rowErrs.Rows[i]
+		if row.Line != tt.line || row.Column != tt.column || (tt.err != nil && !errors.Is(row, tt.err)) {
+			t.Errorf("error %d: line %d, column %q, %v; want line %d, column %q, %v",
+				i, row.Line, row.Column, row.Err, tt.line, tt.column, tt.err)
+		}
+	}
+	if !strings.Contains(err.Error(), "linia 5, kolumna \"Close/Last\"") {
+		t.Errorf("error message %q does not name line 5 and its column", err)
+	}
+}
+
+func TestMaxRows(t *testing.T) {
+	input := "Date,Close/Last,Volume\n10/04/2024,$1,1\n10/03/2024,$2,2\n10/02/2024,$3,3\n"
+	items, err := ReadAll[quote](strings.NewReader(input), Options{MaxRows: 2})
+	if err != nil || len(items) != 2 {
+		t.Errorf("got %d rows, %v; want 2", len(items), err)
+	}
+}
+
+func TestBindFieldErrors(t *testing.T) {
+	type unsupported struct {
+		Values []int
+	}
+	type unknownParser struct {
+		Close float64 `csv:"Close,parse=euros"`
+	}
+	type wrongParserType struct {
+		Close string `csv:"Close,parse=dollars"`
+	}
+	type unknownOption struct {
+		Close float64 `csv:"Close,required"`
+	}
+	type unexportedOnly struct {
+		close float64
+	}
+
+	if _, err := ReadAll[quote](strings.NewReader("Date,Volume\n"), Options{}); !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("missing column: %v, want %v", err, ErrMissingColumn)
+	}
+	if _, err := ReadAll[unsupported](strings.NewReader("Values\n1\n"), Options{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("slice field: %v, want %v", err, ErrUnsupportedType)
+	}
+	if _, err := ReadAll[int](strings.NewReader("Values\n1\n"), Options{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("non-struct type: %v, want %v", err, ErrUnsupportedType)
+	}
+	if _, err := ReadAll[unknownParser](strings.NewReader("Close\n1\n"), Options{}); !errors.Is(err, ErrUnknownParser) {
+		t.Errorf("unknown parser: %v, want %v", err, ErrUnknownParser)
+	}
+	if _, err := ReadAll[unknownOption](strings.NewReader("Close\n1\n"), Options{}); err == nil {
+		t.Error("unknown tag option did not return an error")
+	}
+
+	// parser zwracający inny typ niż pole to błąd wiersza, a nie panika
+	_, err := ReadAll[wrongParserType](strings.NewReader("Close\n$1\n"), Options{})
+	var rowErrs *RowErrors
+	if !errors.As(err, &rowErrs) || !errors.Is(rowErrs.Rows[0], ErrUnsupportedType) {
+		t.Errorf("parser of a different type: %v, want %v", err, ErrUnsupportedType)
+	}
+
+	// pola nieeksportowane są pomijane, nawet jeśli jest dla nich kolumna
+	items, err := ReadAll[unexportedOnly](strings.NewReader("close\n1\n"), Options{})
+	if err != nil || len(items) != 1 || items[0].close != 0 {
+		t.Errorf("unexported field: %+v, %v", items, err)
+	}
+
+	// parser z Options ma pierwszeństwo przed Parsers
+	type custom struct {
+		Close float64 `csv:"Close,parse=dollars"`
+	}
+	opts := Options{Parsers: map[string]ParseFunc{"dollars": func(string) (any, error) { return 42.0, nil }}}
+	if items, err := ReadAll[custom](strings.NewReader("Close\n$1\n"), opts); err != nil || items[0].Close != 42 {
+		t.Errorf("custom parser: %+v, %v", items, err)
+	}
+}
+
+func TestFieldTypes(t *testing.T) {
+	type row struct {
+		Flag  bool
+		Small int8
+		Count uint16
+		Ratio float32
+		Day   time.Time `csv:"Day,layout=02.01.2006"`
+		Since time.Time
+	}
+	items, err := ReadAll[row](strings.NewReader("Flag;Small;Count;Ratio;Day;Since\ntrue;-5;65535;0.5;04.10.2024;2024-10-04\n"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := items[0]
+	if !got.Flag || got.Small != -5 || got.Count != 65535 || got.Ratio != 0.5 ||
+		!got.Day.Equal(date(2024, 10, 4)) || !got.Since.Equal(date(2024, 10, 4)) {
+		t.Errorf("got %+v", got)
+	}
+
+	// wartości spoza zakresu typu pola
+	_, err = ReadAll[row](strings.NewReader("Flag;Small;Count;Ratio;Day;Since\nyes;128;-1;x;2024-10-04;04.10.2024\n"), Options{})
+	var rowErrs *RowErrors
+	if !errors.As(err, &rowErrs) || len(rowErrs.Rows) != 1 || rowErrs.Rows[0].Column != "Flag" {
+		t.Errorf("got %v, want an error in column Flag", err)
+	}
+	for _, input := range []string{"true;128;1;1;04.10.2024;2024-10-04", "true;1;-1;1;04.10.2024;2024-10-04"} {
+		if _, err := ReadAll[row](strings.NewReader("Flag;Small;Count;Ratio;Day;Since\n"+input+"\n"), Options{}); err == nil {
+			t.Errorf("%s: out of range value did not return an error", input)
+		}
+	}
+}
@@ -3,10 +3,10 @@ package models
 import "time"
 
 type NVIDIA struct {
-	Date      time.Time
-	CloseLast float64
-	Volume    int64
-	Open      float64
-	High      float64
-	Low       float64
+	Date      time.Time `csv:"Date,parse=usdate"`
+	CloseLast float64   `csv:"Close/Last,parse=dollars"`
+	Volume    int64     `csv:"Volume"`
+	Open      float64   `csv:"Open,parse=dollars"`
+	High      float64   `csv:"High,parse=dollars"`
+	Low       float64   `csv:"Low,parse=dollars"`
 }
@@ -1,21 +1,16 @@
 package utils
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"os"
-	"strconv"
-	"strings"
-	"time"
 
+	"lab6/delimited"
 	"lab6/models"
 )
 
 func ParseDollarsToFloat(str string) float64 {
-	cleanStr := strings.Replace(str, "$", "", 1)
-	floatVal, err := strconv.ParseFloat(cleanStr, 64)
+	floatVal, err := delimited.ParseDollars(str)
 	if err != nil {
 		fmt.Println("Error parsing float")
 		return 0
@@ -23,52 +18,16 @@ func ParseDollarsToFloat(str string) float64 {
 	return floatVal
 }
 
+// ReadCSV wczytuje notowania; błędne wiersze są wypisywane i pomijane
 func ReadCSV(filepath string) []models.NVIDIA {
-	var historicalData []models.NVIDIA
-
-	file, err := os.Open(filepath)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	reader.Comma = ','
-
-	_, err = reader.Read()
-
-	for i := 0; i < 249; i++ {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
+	historicalData, err := delimited.ReadFile[models.NVIDIA](filepath, delimited.Options{})
+	var rowErrs *delimited.RowErrors
+	if errors.As(err, &rowErrs) {
+		for _, rowErr := range rowErrs.Rows {
+			fmt.Println("Error parsing row:", rowErr)
 		}
-
-		date := record[0]
-		layout := "01/02/2006"
-		parsedTime, err := time.Parse(layout, date)
-		if err != nil {
-			fmt.Println("Error parsing date:", date)
-			return []models.NVIDIA{}
-		}
-
-		closeLast := ParseDollarsToFloat(record[1])
-		volume, err := strconv.ParseInt(record[2], 10, 64)
-		if err != nil {
-			fmt.Println("Error parsing volume")
-			return []models.NVIDIA{}
-		}
-		open := ParseDollarsToFloat(record[3])
-		high := ParseDollarsToFloat(record[4])
-		low := ParseDollarsToFloat(record[5])
-
-		historicalData = append(historicalData, models.NVIDIA{
-			Date:      parsedTime,
-			CloseLast: closeLast,
-			Volume:    volume,
-			Open:      open,
-			High:      high,
-			Low:       low,
-		})
+	} else if err != nil {
+		log.Fatal(err)
 	}
 	return historicalData
 }